@@ -0,0 +1,126 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// Package analysis grades the statistical quality of hash functions such as LXRHash.
+//
+// Analyze runs a set of statistical tests on any func([]byte) []byte, each giving a
+// p-value and a verdict, and Compare runs the same tests on SHA-256 alongside as a
+// baseline.  The tests are
+//
+//   - Bytes: a chi-square test of the distribution of the byte values of the hashes
+//   - Avalanche: the strict avalanche criterion, a chi-square test over the matrix of how
+//     often each output bit flips when each input bit is flipped, which should be half the
+//     time for every pair
+//   - Independence: the bit independence criterion, the largest correlation between the
+//     flips of any two output bits, corrected for the number of pairs
+//   - Runs: a runs test of the bit stream of the hashes of consecutive counters
+//
+// The inputs are drawn from a math/rand source seeded by the Config, so a run is
+// reproducible.  Gradehash keeps the running statistics printed by the comparisons of the
+// testing directory.
+package analysis
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// HashFunc is a hash function under analysis
+type HashFunc func([]byte) []byte
+
+// SHA256 is the baseline the hash functions are compared against
+func SHA256(src []byte) []byte {
+	h := sha256.Sum256(src)
+	return h[:]
+}
+
+// Config sets the size of the tests
+type Config struct {
+	Samples   int     // Number of random inputs hashed by each test
+	InputSize int     // Size of the random inputs in bytes
+	Seed      int64   // Seed of the random inputs
+	Alpha     float64 // Significance level, below which a p-value fails
+}
+
+// DefaultConfig is large enough to catch a poorly mixing hash, and takes a few seconds for
+// LXRHash with a 1GB ByteMap, where every lookup misses the caches
+var DefaultConfig = Config{Samples: 250, InputSize: 16, Seed: 1, Alpha: 0.001}
+
+// Result is the outcome of one statistical test
+type Result struct {
+	Test      string  // Name of the test
+	Statistic float64 // Test statistic
+	PValue    float64 // Probability of a statistic at least as extreme from an ideal hash
+	Pass      bool    // PValue is at least the Alpha of the Config
+	Detail    string  // Human readable summary of what was measured
+}
+
+// Report is the outcome of all the tests on a hash function
+type Report struct {
+	Name    string
+	Results []Result
+}
+
+// Pass reports if every test passed
+func (r Report) Pass() bool {
+	for _, res := range r.Results {
+		if !res.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the report as a table, one test per line
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", r.Name)
+	for _, res := range r.Results {
+		fmt.Fprintf(&b, "  %-12s %-4s p=%-10.4g stat=%-12.4g %s\n", res.Test, verdict(res.Pass), res.PValue, res.Statistic, res.Detail)
+	}
+	return b.String()
+}
+
+func verdict(pass bool) string {
+	if pass {
+		return "PASS"
+	}
+	return "FAIL"
+}
+
+// Analyze runs every test on the hash function
+func Analyze(name string, h HashFunc, cfg Config) Report {
+	// Avalanche and Independence share the hashes of the flipped inputs
+	matrix, columns := flips(h, cfg)
+	return Report{
+		Name: name,
+		Results: []Result{
+			Bytes(h, cfg),
+			avalanche(cfg, matrix),
+			independence(cfg, columns),
+			Runs(h, cfg),
+		},
+	}
+}
+
+// Compare runs every test on the hash function and on SHA-256, with the same inputs
+func Compare(name string, h HashFunc, cfg Config) (hash, sha Report) {
+	return Analyze(name, h, cfg), Analyze("SHA-256", SHA256, cfg)
+}
+
+// inputs returns a source of the random inputs of a test
+func inputs(cfg Config) func() []byte {
+	r := rand.New(rand.NewSource(cfg.Seed))
+	return func() []byte {
+		src := make([]byte, cfg.InputSize)
+		r.Read(src)
+		return src
+	}
+}
+
+// result fills in the verdict of a result
+func result(cfg Config, test string, stat, p float64, detail string) Result {
+	return Result{Test: test, Statistic: stat, PValue: p, Pass: p >= cfg.Alpha, Detail: detail}
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+var testConfig = Config{Samples: 200, InputSize: 8, Seed: 1, Alpha: 0.001}
+
+func TestCompare(t *testing.T) {
+	lx := new(lxr.LXRHash)
+	lx.Init(lxr.Seed, 16, lxr.HashSize, lxr.Passes)
+
+	hash, sha := Compare("LXRHash", lx.Hash, testConfig)
+	if !sha.Pass() {
+		t.Errorf("SHA-256 failed:\n%s", sha)
+	}
+	if !hash.Pass() {
+		t.Errorf("LXRHash failed:\n%s", hash)
+	}
+	if len(hash.Results) != 4 || !strings.Contains(hash.String(), "Avalanche") {
+		t.Errorf("unexpected report:\n%s", hash)
+	}
+}
+
+func TestAnalyze_Fails(t *testing.T) {
+	// Each broken hash fails the test that looks for its flaw
+	tests := []struct {
+		test   func(HashFunc, Config) Result
+		name   string
+		broken HashFunc
+	}{
+		{Bytes, "zero first byte", func(src []byte) []byte {
+			h := SHA256(src)
+			h[0] = 0
+			return h
+		}},
+		{Avalanche, "copied input", func(src []byte) []byte {
+			h := SHA256(src)
+			copy(h, src)
+			return h
+		}},
+		{Independence, "duplicated bit", func(src []byte) []byte {
+			h := SHA256(src)
+			h[0] = h[0]&^2 | (h[0]&1)<<1
+			return h
+		}},
+		{Independence, "constant bit", func(src []byte) []byte {
+			h := SHA256(src)
+			h[5] |= 8
+			return h
+		}},
+		{Runs, "alternating bits", func(src []byte) []byte {
+			h := SHA256(src)
+			for i := 0; i < len(h); i += 2 {
+				h[i] = 0x55
+			}
+			return h
+		}},
+	}
+	for _, tt := range tests {
+		if res := tt.test(SHA256, testConfig); !res.Pass {
+			t.Errorf("%s failed on SHA-256: %+v", res.Test, res)
+		}
+		if res := tt.test(tt.broken, testConfig); res.Pass {
+			t.Errorf("%s passed a hash with a %s: %+v", res.Test, tt.name, res)
+		}
+	}
+}
+
+func TestGradehash(t *testing.T) {
+	var g Gradehash
+	for i := 0; i < 100; i++ {
+		src := []byte{byte(i)}
+		g.Start()
+		h := SHA256(src)
+		g.Stop()
+		g.AddHash(src, h)
+	}
+	if count, report := g.Report("sha"); count != "100" || !strings.Contains(report, "sha") {
+		t.Errorf("unexpected report %s %s", count, report)
+	}
+	if Comma(1234567) != "1,234,567" || Difficulty([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9}) != 0x0102030405060708 {
+		t.Error("unexpected Comma or Difficulty")
+	}
+}
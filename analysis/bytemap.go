@@ -0,0 +1,277 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+// CacheLine is the size of the cache lines the entropy of a ByteMap is measured over
+const CacheLine = 64
+
+// TableReport is the outcome of the analysis of a ByteMap.
+//
+// GenerateTable fills the ByteMap with the values 0 to 255 over and over, then shuffles it
+// Passes times.  The shuffle keeps the count of every value, and should leave no trace of
+// the fill, nor any structure between neighbouring entries.
+//
+// The cycle structure reads every aligned block of 256 entries as a function on the byte
+// values, from x to the entry at x.  The fixed points of the functions are the entries that
+// still hold the value they were filled with.  In a 256 byte table the block is a
+// permutation, and is compared with a random permutation.  In larger tables, the blocks are
+// compared with random functions.
+type TableReport struct {
+	Size   uint64 // Entries in the table
+	Passes uint64 // Passes of the shuffle, if known
+
+	MinCount, MaxCount uint64 // Fewest and most entries of any byte value
+
+	Correlation float64 // Serial correlation of adjacent entries, wrapping around the end
+
+	LineDistinct, ExpectedLineDistinct float64 // Mean distinct values in a cache line
+	LineEntropy                        float64 // Mean Shannon entropy of a cache line in bits
+
+	FixedPoints, ExpectedFixedPoints   float64 // Entries holding the value they were filled with
+	Cycles, ExpectedCycles             float64 // Mean cycles of the function of a block
+	CyclicPoints, ExpectedCyclicPoints float64 // Mean values on cycles of the function of a block
+	LongestCycle                       int     // Longest cycle of any block
+
+	Results []Result // Verdicts on uniformity, correlation, cache lines and fixed points
+}
+
+// Pass reports if every test passed
+func (r TableReport) Pass() bool {
+	return Report{Results: r.Results}.Pass()
+}
+
+// String formats the report, the measurements followed by the verdicts
+func (r TableReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ByteMap of %d bytes", r.Size)
+	if r.Passes != 0 {
+		fmt.Fprintf(&b, ", %d passes", r.Passes)
+	}
+	fmt.Fprintf(&b, "\n  counts        %d to %d of each value\n", r.MinCount, r.MaxCount)
+	fmt.Fprintf(&b, "  correlation   %.6f between adjacent entries\n", r.Correlation)
+	fmt.Fprintf(&b, "  cache lines   %.3f distinct values (%.3f expected), %.4f bits of entropy\n",
+		r.LineDistinct, r.ExpectedLineDistinct, r.LineEntropy)
+	fmt.Fprintf(&b, "  fixed points  %.0f (%.0f expected)\n", r.FixedPoints, r.ExpectedFixedPoints)
+	fmt.Fprintf(&b, "  cycles        %.3f per block (%.3f expected), %.3f values on cycles (%.3f expected), longest %d\n",
+		r.Cycles, r.ExpectedCycles, r.CyclicPoints, r.ExpectedCyclicPoints, r.LongestCycle)
+	b.WriteString(Report{Name: "  verdicts", Results: r.Results}.String())
+	return b.String()
+}
+
+// AnalyzeTable analyses a ByteMap, whose size must be a multiple of 256.  Only the Alpha of
+// the Config is used.  A 1GB ByteMap takes about half a minute.
+func AnalyzeTable(table []byte, cfg Config) TableReport {
+	r := TableReport{Size: uint64(len(table))}
+	n := float64(len(table))
+	if len(table) == 0 || len(table)%256 != 0 {
+		r.Results = []Result{result(cfg, "Uniformity", 0, 0, "size is not a multiple of 256")}
+		return r
+	}
+
+	// Exact uniformity of the counts
+	var counts [256]uint64
+	for _, v := range table {
+		counts[v]++
+	}
+	r.MinCount, r.MaxCount = counts[0], counts[0]
+	for _, c := range counts {
+		if c < r.MinCount {
+			r.MinCount = c
+		}
+		if c > r.MaxCount {
+			r.MaxCount = c
+		}
+	}
+	uniform := 0.0
+	if r.MinCount == r.MaxCount {
+		uniform = 1
+	}
+	r.Results = append(r.Results, result(cfg, "Uniformity", float64(r.MaxCount-r.MinCount), uniform,
+		fmt.Sprintf("%d to %d of each value, %d expected", r.MinCount, r.MaxCount, len(table)/256)))
+
+	// Serial correlation of adjacent entries.  The counts are uniform, so the mean and
+	// variance are those of the values 0 to 255.
+	const mean, variance = 127.5, (256*256 - 1) / 12.0
+	sum := 0.0
+	for i, v := range table {
+		sum += (float64(v) - mean) * (float64(table[(i+1)%len(table)]) - mean)
+	}
+	r.Correlation = sum / n / variance
+	z := r.Correlation * math.Sqrt(n)
+	r.Results = append(r.Results, result(cfg, "Adjacent", z, NormalP(z),
+		fmt.Sprintf("correlation %.6f between adjacent entries", r.Correlation)))
+
+	// Distinct values and entropy of cache lines
+	line := CacheLine
+	if len(table) < line {
+		line = len(table)
+	}
+	lines := len(table) / line
+	for l := 0; l < lines; l++ {
+		var seen [256]int
+		for _, v := range table[l*line : (l+1)*line] {
+			seen[v]++
+		}
+		for _, c := range seen {
+			if c > 0 {
+				r.LineDistinct++
+				p := float64(c) / float64(line)
+				r.LineEntropy -= p * math.Log2(p)
+			}
+		}
+	}
+	r.LineDistinct /= float64(lines)
+	r.LineEntropy /= float64(lines)
+	var lineVariance float64
+	r.ExpectedLineDistinct, lineVariance = distinct(len(table), line)
+	z = 0
+	if lineVariance > 0 {
+		z = (r.LineDistinct - r.ExpectedLineDistinct) / math.Sqrt(lineVariance/float64(lines))
+	}
+	r.Results = append(r.Results, result(cfg, "CacheLines", z, NormalP(z),
+		fmt.Sprintf("%.3f distinct values in %d byte lines, %.3f expected", r.LineDistinct, line, r.ExpectedLineDistinct)))
+
+	// Cycle structure of the blocks
+	blocks := len(table) / 256
+	for k := 0; k < blocks; k++ {
+		cycles, cyclic, longest, fixed := cycleStructure(table[k*256 : (k+1)*256])
+		r.Cycles += float64(cycles)
+		r.CyclicPoints += float64(cyclic)
+		r.FixedPoints += float64(fixed)
+		if longest > r.LongestCycle {
+			r.LongestCycle = longest
+		}
+	}
+	r.Cycles /= float64(blocks)
+	r.CyclicPoints /= float64(blocks)
+	if blocks == 1 {
+		r.ExpectedCycles, r.ExpectedCyclicPoints = permutationCycles(256)
+	} else {
+		r.ExpectedCycles, r.ExpectedCyclicPoints = functionCycles(256)
+	}
+	r.ExpectedFixedPoints = n / 256
+	z = (r.FixedPoints - r.ExpectedFixedPoints) / math.Sqrt(n/256*255/256)
+	r.Results = append(r.Results, result(cfg, "FixedPoints", z, NormalP(z),
+		fmt.Sprintf("%.0f entries hold the value they were filled with, %.0f expected", r.FixedPoints, r.ExpectedFixedPoints)))
+	return r
+}
+
+// ComparePasses generates the ByteMap for the seed and size with each number of passes, and
+// analyses each of them.  The tables are generated in memory, and not cached.
+func ComparePasses(seed, bits uint64, passes []uint64, cfg Config) []TableReport {
+	var reports []TableReport
+	for _, p := range passes {
+		lx := lxr.LXRHash{Seed: seed, MapSize: uint64(1) << bits, MapSizeBits: bits, Passes: p}
+		lx.GenerateTable()
+		r := AnalyzeTable(lx.ByteMap, cfg)
+		r.Passes = p
+		reports = append(reports, r)
+		lx.Close()
+	}
+	return reports
+}
+
+// FormatPasses formats the reports of ComparePasses as a table, one row per number of passes
+func FormatPasses(reports []TableReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%6s %12s %12s %12s %12s %10s %12s %s\n",
+		"passes", "correlation", "line values", "line bits", "fixed", "cycles", "cyclic", "verdict")
+	for _, r := range reports {
+		var failed []string
+		for _, res := range r.Results {
+			if !res.Pass {
+				failed = append(failed, res.Test)
+			}
+		}
+		v := "PASS"
+		if len(failed) > 0 {
+			v = "FAIL " + strings.Join(failed, ",")
+		}
+		fmt.Fprintf(&b, "%6d %12.6f %12.3f %12.4f %12.0f %10.3f %12.3f %s\n",
+			r.Passes, r.Correlation, r.LineDistinct, r.LineEntropy, r.FixedPoints, r.Cycles, r.CyclicPoints, v)
+	}
+	if len(reports) > 0 {
+		r := reports[0]
+		fmt.Fprintf(&b, "%6s %12.6f %12.3f %12s %12.0f %10.3f %12.3f\n",
+			"ideal", 0.0, r.ExpectedLineDistinct, "", r.ExpectedFixedPoints, r.ExpectedCycles, r.ExpectedCyclicPoints)
+	}
+	return b.String()
+}
+
+// cycleStructure returns the number of cycles, the number of values on cycles, the length
+// of the longest cycle and the number of fixed points of the function x -> block[x]
+func cycleStructure(block []byte) (cycles, cyclic, longest, fixed int) {
+	// 0 is unvisited, 1 is on the current path, 2 is done
+	var state [256]byte
+	for start := 0; start < 256; start++ {
+		if block[start] == byte(start) {
+			fixed++
+		}
+		x := start
+		for state[x] == 0 {
+			state[x] = 1
+			x = int(block[x])
+		}
+		if state[x] == 1 {
+			// A new cycle through x
+			length := 1
+			for y := int(block[x]); y != x; y = int(block[y]) {
+				length++
+			}
+			cycles++
+			cyclic += length
+			if length > longest {
+				longest = length
+			}
+		}
+		for y := start; state[y] == 1; y = int(block[y]) {
+			state[y] = 2
+		}
+	}
+	return cycles, cyclic, longest, fixed
+}
+
+// permutationCycles returns the expected number of cycles and values on cycles of a random
+// permutation of n values
+func permutationCycles(n int) (cycles, cyclic float64) {
+	for k := 1; k <= n; k++ {
+		cycles += 1 / float64(k)
+	}
+	return cycles, float64(n)
+}
+
+// functionCycles returns the expected number of cycles and values on cycles of a random
+// function on n values.  A given k values form a cycle with probability (k-1)!/n^k.
+func functionCycles(n int) (cycles, cyclic float64) {
+	falling := 1.0 // n!/((n-k)! n^k)
+	for k := 1; k <= n; k++ {
+		falling *= float64(n-k+1) / float64(n)
+		cycles += falling / float64(k)
+		cyclic += falling
+	}
+	return cycles, cyclic
+}
+
+// distinct returns the mean and variance of the number of distinct values in a line of m
+// entries of a shuffled table of size entries, with size/256 of each value
+func distinct(size, m int) (mean, variance float64) {
+	c := size / 256
+	absent, bothAbsent := 1.0, 1.0 // Probability one value, or two values, are absent from a line
+	for j := 0; j < m; j++ {
+		absent *= float64(size-c-j) / float64(size-j)
+		bothAbsent *= math.Max(0, float64(size-2*c-j)) / float64(size-j)
+	}
+	present := 1 - absent
+	bothPresent := 1 - 2*absent + bothAbsent
+	mean = 256 * present
+	variance = 256*present*(1-present) + 256*255*(bothPresent-present*present)
+	return mean, math.Max(0, variance)
+}
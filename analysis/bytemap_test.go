@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+func TestAnalyzeTable(t *testing.T) {
+	reports := ComparePasses(lxr.Seed, 14, []uint64{0, lxr.Passes}, testConfig)
+	if len(reports) != 2 || reports[1].Passes != lxr.Passes {
+		t.Fatalf("unexpected reports %+v", reports)
+	}
+	if shuffled := reports[1]; !shuffled.Pass() || shuffled.MinCount != 64 || shuffled.MaxCount != 64 {
+		t.Errorf("generated table failed:\n%s", shuffled)
+	}
+
+	// The unshuffled fill is all structure
+	failed := make(map[string]bool)
+	for _, res := range reports[0].Results {
+		failed[res.Test] = !res.Pass
+	}
+	if !failed["Adjacent"] || !failed["FixedPoints"] || !failed["CacheLines"] || failed["Uniformity"] {
+		t.Errorf("unexpected verdicts on the unshuffled table:\n%s", reports[0])
+	}
+	if !strings.Contains(FormatPasses(reports), "ideal") {
+		t.Errorf("unexpected format:\n%s", FormatPasses(reports))
+	}
+
+	// Uneven counts fail uniformity
+	table := append([]byte{}, make([]byte, 512)...)
+	if r := AnalyzeTable(table, testConfig); r.Results[0].Pass || r.MaxCount != 512 {
+		t.Errorf("uneven table passed:\n%s", r)
+	}
+	if r := AnalyzeTable(make([]byte, 100), testConfig); r.Pass() {
+		t.Error("table of 100 bytes passed")
+	}
+}
+
+func TestCycleStructure(t *testing.T) {
+	// The identity, then a single cycle through every value, then everything to 0
+	var identity, rotate, zero [256]byte
+	for i := range identity {
+		identity[i] = byte(i)
+		rotate[i] = byte(i + 1)
+	}
+	tests := []struct {
+		block                          []byte
+		cycles, cyclic, longest, fixed int
+	}{
+		{identity[:], 256, 256, 1, 256},
+		{rotate[:], 1, 256, 256, 0},
+		{zero[:], 1, 1, 1, 1},
+	}
+	for i, tt := range tests {
+		cycles, cyclic, longest, fixed := cycleStructure(tt.block)
+		if cycles != tt.cycles || cyclic != tt.cyclic || longest != tt.longest || fixed != tt.fixed {
+			t.Errorf("%d: got %d %d %d %d", i, cycles, cyclic, longest, fixed)
+		}
+	}
+
+	// A random function on n values has about sqrt(pi n / 2) values on cycles
+	if _, cyclic := functionCycles(256); math.Abs(cyclic-math.Sqrt(math.Pi*256/2)) > 1 {
+		t.Errorf("expected about %.2f values on cycles, got %.2f", math.Sqrt(math.Pi*256/2), cyclic)
+	}
+	// A permutation of 256 values leaves every line of 64 distinct
+	if mean, variance := distinct(256, 64); math.Abs(mean-64) > 1e-9 || variance > 1e-9 {
+		t.Errorf("expected 64 distinct values with no variance, got %v and %v", mean, variance)
+	}
+}
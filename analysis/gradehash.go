@@ -1,18 +1,14 @@
 // Copyright (c) of parts are held by the various contributors
 // Licensed under the MIT License. See LICENSE file in the project root for full license information.
-package testing_test
+package analysis
 
 import (
-	rand2 "crypto/rand"
 	"fmt"
 	"time"
 )
 
-// Routines for collecting stats on Hashing algorithms and comparing them to other
-// implementations and libraries.
-
-var runStart int64
-
+// Gradehash collects running statistics on the hashes of a hash function while they are
+// computed, and reports them for comparing it to other implementations and libraries.
 type Gradehash struct {
 	bytefrequency [256]int
 	numhashes     int
@@ -30,6 +26,7 @@ type Gradehash struct {
 	diffHash      []byte
 }
 
+// PrintHeader prints the key to the columns of Report
 func (g Gradehash) PrintHeader() {
 	fmt.Print("------------------------------------\n" +
 		"Key For Data Printed while tests run:\n\n" +
@@ -54,16 +51,13 @@ func (g Gradehash) PrintHeader() {
 		"------------------------------------\n")
 }
 
+// AddHash adds the hash of src to the statistics
 func (g *Gradehash) AddHash(src []byte, hash []byte) {
 
 	for len(hash) > len(g.positionSums) {
 		g.positionSums = append(g.positionSums, 0)
 	}
 
-	if runStart == 0 {
-		runStart = time.Now().Unix()
-	}
-
 	for _, v := range hash {
 		g.bytefrequency[v]++
 	}
@@ -104,16 +98,18 @@ func (g *Gradehash) AddHash(src []byte, hash []byte) {
 
 }
 
+// Start starts timing a hash
 func (g *Gradehash) Start() {
 	g.start = time.Now().UnixNano()
 }
 
+// Stop stops timing a hash, and adds the time to the total
 func (g *Gradehash) Stop() {
 	diff := time.Now().UnixNano() - g.start
 	g.exctime += diff
 }
 
-// return the count of the number of hashes performed.
+// Report returns the count of the number of hashes performed, and a line of statistics
 func (g *Gradehash) Report(name string) (hashcount string, report string) {
 
 	if g.numhashes == 0 {
@@ -177,6 +173,7 @@ func (g *Gradehash) Report(name string) (hashcount string, report string) {
 	return
 }
 
+// Difficulty returns the high order eight bytes of the hash as a number, like mining.
 // Consider a bigger number to be more difficult.  (This is a bit different
 // than most PoW.  It is the same as viewing the return value as signed, and
 // saying a smaller value is more difficult, due to the nature of signed
@@ -190,16 +187,7 @@ func Difficulty(hash []byte) uint64 {
 	return diff
 }
 
-func Getbuf(length int) []byte {
-	//buflen := minsample + rand.Intn(maxsample)
-	nbuf := make([]byte, length)
-	_, err := rand2.Reader.Read(nbuf)
-	if err != nil {
-		panic(err)
-	}
-	return nbuf
-}
-
+// Comma formats n with commas between the thousands
 func Comma(n uint64) string {
 	if n == 0 {
 		return "0"
@@ -0,0 +1,264 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+// Page is the size of the memory pages the reuse of the ByteMap is measured over
+const Page = 4096
+
+// IndexBuckets is the number of equal ranges of the ByteMap the lookups are counted in
+const IndexBuckets = 32
+
+// Reuse is the histogram of the reuse distances of the cache lines or pages of the ByteMap.
+// The reuse distance of a lookup is the number of lookups since the last lookup in the same
+// line or page, 1 for the lookup just before.  It is counted over every hash of a Profile,
+// as a cache would see them.
+//
+// For lookups spread uniformly and independently over the ByteMap, the distances follow a
+// geometric distribution, which gives the expected counts.  Lookups that come back to a
+// line sooner than that could be served by a small cache next to the hashing core.
+type Reuse struct {
+	Size   uint64   // Bytes in each line or page
+	Units  uint64   // Lines or pages in the ByteMap
+	First  uint64   // Lookups of a line or page not looked up before
+	Counts []uint64 // Counts[k] is the lookups with a reuse distance of 2^k to 2^(k+1)-1
+
+	last map[uint64]uint64 // Lookup number of the last lookup of each line or page
+}
+
+func newReuse(size, mapSize uint64) Reuse {
+	if size > mapSize {
+		size = mapSize
+	}
+	return Reuse{Size: size, Units: mapSize / size, last: make(map[uint64]uint64)}
+}
+
+// add records lookup number t of the index
+func (r *Reuse) add(t, index uint64) {
+	unit := index / r.Size
+	last, ok := r.last[unit]
+	r.last[unit] = t
+	if !ok {
+		r.First++
+		return
+	}
+	k := bits.Len64(t-last) - 1
+	for len(r.Counts) <= k {
+		r.Counts = append(r.Counts, 0)
+	}
+	r.Counts[k]++
+}
+
+// Expected returns the expected counts of the histogram for lookups uniformly spread over the
+// ByteMap, given the total number of lookups
+func (r Reuse) Expected(lookups uint64) (first float64, counts []float64) {
+	n := float64(lookups)
+	q := 1 - 1/float64(r.Units) // Probability a lookup misses a given line or page
+	p := 1 - q
+	pow := func(e float64) float64 { return math.Pow(q, e) }
+
+	// The lookup after t others is a first lookup with probability q^t, and has a reuse
+	// distance of j <= t with probability q^(j-1) p
+	first = (1 - pow(n)) / p
+	for a := 1.0; a < n; a *= 2 {
+		b := 2 * a
+		e := 0.0
+		// Lookups with a <= t < b-1 can only reach part of the bucket
+		if m := math.Min(n-1, b-2); m >= a {
+			e += (m-a+1)*pow(a-1) - (pow(a)-pow(m+1))/p
+		}
+		// Lookups with t >= b-1 can reach all of it
+		if n-b+1 > 0 {
+			e += (n - b + 1) * (pow(a-1) - pow(b-1))
+		}
+		counts = append(counts, e)
+	}
+	return first, counts
+}
+
+// result compares the histogram to the expected counts with a chi-square test, merging
+// the buckets until each expects at least 5 lookups
+func (r Reuse) result(cfg Config, test string, lookups uint64) Result {
+	first, expected := r.Expected(lookups)
+	observed := func(k int) float64 {
+		if k < len(r.Counts) {
+			return float64(r.Counts[k])
+		}
+		return 0
+	}
+
+	stat, cells := 0.0, 0
+	cell := func(o, e float64) {
+		stat += (o - e) * (o - e) / e
+		cells++
+	}
+	if first > 0 {
+		cell(float64(r.First), first)
+	}
+	worst, worstK := 0.0, 0 // The bucket furthest from its expected count, in standard deviations
+	var o, e float64
+	for k := range expected {
+		if expected[k] >= 5 {
+			if z := (observed(k) - expected[k]) / math.Sqrt(expected[k]); math.Abs(z) > math.Abs(worst) {
+				worst, worstK = z, k
+			}
+		}
+		o += observed(k)
+		e += expected[k]
+		if e >= 5 {
+			cell(o, e)
+			o, e = 0, 0
+		}
+	}
+	if e > 0 {
+		cell(o, e)
+	}
+	if cells < 2 {
+		return result(cfg, test, 0, 1, fmt.Sprintf("%d lookups are too few to test", lookups))
+	}
+	detail := fmt.Sprintf("%d first lookups of %d, %.0f expected, worst %+.1f sd at distance %d",
+		r.First, r.Units, first, worst, uint64(1)<<uint(worstK))
+	return result(cfg, test, stat, ChiSquareP(stat, cells-1), detail)
+}
+
+// Profile collects the lookups of the ByteMap made by TraceHash over a number of hashes.  It
+// is an lxr.Tracer, and counts the lookups in each Phase and per hash, the lookups in each
+// of IndexBuckets ranges of the ByteMap, and the reuse of cache lines and pages.
+type Profile struct {
+	MapSize uint64
+	Hashes  uint64
+	Lookups uint64
+
+	PhaseLookups           []uint64 // Lookups in each lxr.Phase
+	MinLookups, MaxLookups uint64   // Fewest and most lookups made by a hash
+
+	Buckets      []uint64 // Lookups in each of IndexBuckets equal ranges of the ByteMap
+	Lines, Pages Reuse    // Reuse of the cache lines and pages of the ByteMap
+
+	current uint64 // Lookups of the hash being traced
+}
+
+var _ lxr.Tracer = (*Profile)(nil)
+
+// NewProfile returns an empty Profile of a ByteMap of mapSize bytes
+func NewProfile(mapSize uint64) *Profile {
+	buckets := uint64(IndexBuckets)
+	if buckets > mapSize {
+		buckets = mapSize
+	}
+	return &Profile{
+		MapSize:      mapSize,
+		PhaseLookups: make([]uint64, len(lxr.Phases)),
+		Buckets:      make([]uint64, buckets),
+		Lines:        newReuse(CacheLine, mapSize),
+		Pages:        newReuse(Page, mapSize),
+	}
+}
+
+// Lookup records one lookup of the ByteMap
+func (p *Profile) Lookup(phase lxr.Phase, index uint64) {
+	if int(phase) < len(p.PhaseLookups) {
+		p.PhaseLookups[phase]++
+	}
+	p.Buckets[index/(p.MapSize/uint64(len(p.Buckets)))]++
+	p.Lines.add(p.Lookups, index)
+	p.Pages.add(p.Lookups, index)
+	p.Lookups++
+	p.current++
+}
+
+// Hash traces the hash of src into the profile, and returns the hash
+func (p *Profile) Hash(lx lxr.LXRHash, src []byte) []byte {
+	p.current = 0
+	h := lx.TraceHash(src, p)
+	if p.Hashes == 0 || p.current < p.MinLookups {
+		p.MinLookups = p.current
+	}
+	if p.current > p.MaxLookups {
+		p.MaxLookups = p.current
+	}
+	p.Hashes++
+	return h
+}
+
+// ProfileHash traces the hashes of the random inputs of the Config
+func ProfileHash(lx lxr.LXRHash, cfg Config) *Profile {
+	p := NewProfile(lx.MapSize)
+	next := inputs(cfg)
+	for i := 0; i < cfg.Samples; i++ {
+		p.Hash(lx, next())
+	}
+	return p
+}
+
+// Results tests that the lookups are spread uniformly over the ByteMap, with a chi-square
+// test over the IndexBuckets, and that the reuse of cache lines and of pages follows the
+// distances expected of uniform lookups
+func (p *Profile) Results(cfg Config) []Result {
+	expected := float64(p.Lookups) / float64(len(p.Buckets))
+	stat := 0.0
+	least, most := p.Buckets[0], p.Buckets[0]
+	for _, c := range p.Buckets {
+		stat += (float64(c) - expected) * (float64(c) - expected) / expected
+		if c < least {
+			least = c
+		}
+		if c > most {
+			most = c
+		}
+	}
+	detail := fmt.Sprintf("%d to %d lookups in %d ranges, %.0f expected", least, most, len(p.Buckets), expected)
+	return []Result{
+		result(cfg, "Indexes", stat, ChiSquareP(stat, len(p.Buckets)-1), detail),
+		p.Lines.result(cfg, "LineReuse", p.Lookups),
+		p.Pages.result(cfg, "PageReuse", p.Lookups),
+	}
+}
+
+// String formats the profile, with the histograms of the indexes and of the reuse distances
+func (p *Profile) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Lookups of a ByteMap of %d bytes by %d hashes\n", p.MapSize, p.Hashes)
+	if p.Hashes == 0 {
+		return b.String()
+	}
+	fmt.Fprintf(&b, "  per hash  %.1f (%d to %d)", float64(p.Lookups)/float64(p.Hashes), p.MinLookups, p.MaxLookups)
+	for _, phase := range lxr.Phases {
+		fmt.Fprintf(&b, ", %.1f %s", float64(p.PhaseLookups[phase])/float64(p.Hashes), phase)
+	}
+
+	size := p.MapSize / uint64(len(p.Buckets))
+	expected := float64(p.Lookups) / float64(len(p.Buckets))
+	fmt.Fprintf(&b, "\n\n  %-12s %12s %9s\n", "index", "lookups", "vs ideal")
+	for i, c := range p.Buckets {
+		fmt.Fprintf(&b, "  %012x %12d %+8.2f%%\n", uint64(i)*size, c, 100*(float64(c)/expected-1))
+	}
+
+	for _, r := range []struct {
+		name  string
+		reuse Reuse
+	}{{"cache line", p.Lines}, {"page", p.Pages}} {
+		first, counts := r.reuse.Expected(p.Lookups)
+		fmt.Fprintf(&b, "\n  %d byte %s reuse distance\n", r.reuse.Size, r.name)
+		fmt.Fprintf(&b, "  %-12s %12s %12s\n", "distance", "lookups", "ideal")
+		fmt.Fprintf(&b, "  %-12s %12d %12.1f\n", "first", r.reuse.First, first)
+		for k, e := range counts {
+			var c uint64
+			if k < len(r.reuse.Counts) {
+				c = r.reuse.Counts[k]
+			} else if e < 0.05 {
+				break
+			}
+			fmt.Fprintf(&b, "  %-12d %12d %12.1f\n", uint64(1)<<uint(k), c, e)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+func TestProfileHash(t *testing.T) {
+	lx := new(lxr.LXRHash)
+	lx.Init(lxr.Seed, 16, lxr.HashSize, lxr.Passes)
+
+	p := ProfileHash(*lx, testConfig)
+	perHash := uint64(0)
+	for _, phase := range lxr.Phases {
+		n := phase.Lookups(uint64(testConfig.InputSize), lx.HashSize)
+		if p.PhaseLookups[phase] != n*uint64(testConfig.Samples) {
+			t.Errorf("%d lookups in the %s phase, expected %d", p.PhaseLookups[phase], phase, n*uint64(testConfig.Samples))
+		}
+		perHash += n
+	}
+	if p.Hashes != uint64(testConfig.Samples) || p.MinLookups != perHash || p.MaxLookups != perHash {
+		t.Errorf("%d hashes of %d to %d lookups, expected %d of %d", p.Hashes, p.MinLookups, p.MaxLookups, testConfig.Samples, perHash)
+	}
+
+	// Every lookup is counted once in each histogram
+	total := func(counts []uint64) (sum uint64) {
+		for _, c := range counts {
+			sum += c
+		}
+		return sum
+	}
+	if total(p.Buckets) != p.Lookups || p.Lines.First+total(p.Lines.Counts) != p.Lookups || p.Pages.First+total(p.Pages.Counts) != p.Lookups {
+		t.Errorf("histograms do not add up to %d lookups", p.Lookups)
+	}
+	if res := p.Results(testConfig); !res[0].Pass {
+		t.Errorf("lookups are not spread over the ByteMap: %s", res[0].Detail)
+	}
+	if s := p.String(); !strings.Contains(s, "cache line reuse") || !strings.Contains(s, "page reuse") {
+		t.Errorf("unexpected format:\n%s", s)
+	}
+
+	// Profiling does not change the hash
+	src := []byte("profiled")
+	if !bytes.Equal(NewProfile(lx.MapSize).Hash(*lx, src), lx.Hash(src)) {
+		t.Error("profiled hash differs from Hash")
+	}
+}
+
+func TestProfile_Results(t *testing.T) {
+	const mapSize, lookups = 1 << 16, 200000
+
+	// Uniform lookups pass every test
+	r := rand.New(rand.NewSource(1))
+	uniform := NewProfile(mapSize)
+	for i := 0; i < lookups; i++ {
+		uniform.Lookup(lxr.PhaseStep, uint64(r.Intn(mapSize)))
+	}
+	if res := (Report{Results: uniform.Results(testConfig)}); !res.Pass() {
+		t.Errorf("uniform lookups failed:\n%s", res)
+	}
+
+	// Walking through the ByteMap touches every range the same, but reuses every line at once
+	walk := NewProfile(mapSize)
+	for i := 0; i < 16*mapSize/8; i++ {
+		walk.Lookup(lxr.PhaseStep, uint64(i*8)%mapSize)
+	}
+	if res := walk.Results(testConfig); !res[0].Pass || res[1].Pass {
+		t.Errorf("unexpected verdicts on a walk:\n%s", Report{Results: res})
+	}
+
+	// The expected histogram accounts for every lookup
+	first, counts := uniform.Lines.Expected(lookups)
+	for _, c := range counts {
+		first += c
+	}
+	if math.Abs(first-lookups) > 1e-6*lookups {
+		t.Errorf("expected counts add up to %f, not %d", first, lookups)
+	}
+}
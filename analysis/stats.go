@@ -0,0 +1,68 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package analysis
+
+import "math"
+
+// ChiSquareP returns the p-value of a chi-square statistic with df degrees of freedom, the
+// probability of a statistic at least as large from the expected distribution
+func ChiSquareP(stat float64, df int) float64 {
+	return gammaQ(float64(df)/2, stat/2)
+}
+
+// NormalP returns the two sided p-value of a z score, the probability of a standard normal
+// value at least as far from zero
+func NormalP(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+const (
+	gammaEps  = 1e-15   // Relative accuracy of gammaQ
+	gammaTiny = 1e-300  // Guards the continued fraction against division by zero
+	gammaIter = 1000000 // Bound on the terms, which converge long before for sane arguments
+)
+
+// gammaQ returns the regularized upper incomplete gamma function Q(a, x), using the series
+// for P(a, x) below a+1 and the continued fraction for Q(a, x) above, as in Numerical Recipes
+func gammaQ(a, x float64) float64 {
+	if x <= 0 {
+		return 1
+	}
+	lg, _ := math.Lgamma(a)
+	front := math.Exp(-x + a*math.Log(x) - lg)
+
+	if x < a+1 {
+		sum := 1 / a
+		del := sum
+		for n := 1; n < gammaIter && math.Abs(del) > math.Abs(sum)*gammaEps; n++ {
+			del *= x / (a + float64(n))
+			sum += del
+		}
+		return math.Max(0, 1-sum*front)
+	}
+
+	// Modified Lentz's method
+	b := x + 1 - a
+	c := 1 / gammaTiny
+	d := 1 / b
+	h := d
+	for i := 1; i < gammaIter; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < gammaTiny {
+			d = gammaTiny
+		}
+		c = b + an/c
+		if math.Abs(c) < gammaTiny {
+			c = gammaTiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < gammaEps {
+			break
+		}
+	}
+	return front * h
+}
@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestChiSquareP(t *testing.T) {
+	// Critical values at the 5% and 0.1% levels from the usual tables
+	tests := []struct {
+		stat float64
+		df   int
+		p    float64
+	}{
+		{3.841, 1, 0.05},
+		{10.828, 1, 0.001},
+		{18.307, 10, 0.05},
+		{293.248, 255, 0.05},
+		{330.520, 255, 0.001},
+		{0, 255, 1},
+	}
+	for _, tt := range tests {
+		if p := ChiSquareP(tt.stat, tt.df); math.Abs(p-tt.p) > tt.p*0.01 {
+			t.Errorf("ChiSquareP(%v, %d) = %v, want %v", tt.stat, tt.df, p, tt.p)
+		}
+	}
+	if p := ChiSquareP(255, 255); p < 0.4 || p > 0.6 {
+		t.Errorf("ChiSquareP at the mean = %v, want about a half", p)
+	}
+}
+
+func TestNormalP(t *testing.T) {
+	for z, want := range map[float64]float64{0: 1, 1.96: 0.05, -1.96: 0.05, 3.2905: 0.001} {
+		if p := NormalP(z); math.Abs(p-want) > want*0.01 {
+			t.Errorf("NormalP(%v) = %v, want %v", z, p, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package analysis
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Bytes tests that every byte value is equally likely in the hashes of random inputs, with
+// a chi-square test over the counts of the 256 values
+func Bytes(h HashFunc, cfg Config) Result {
+	var counts [256]float64
+	next := inputs(cfg)
+	total := 0.0
+	for i := 0; i < cfg.Samples; i++ {
+		for _, v := range h(next()) {
+			counts[v]++
+			total++
+		}
+	}
+
+	expected := total / 256
+	stat := 0.0
+	most, least := 0, 0
+	for v, c := range counts {
+		stat += (c - expected) * (c - expected) / expected
+		if c > counts[most] {
+			most = v
+		}
+		if c < counts[least] {
+			least = v
+		}
+	}
+	detail := fmt.Sprintf("%.0f bytes, most %02x (%+.2f%%), least %02x (%+.2f%%)", total,
+		most, 100*(counts[most]/expected-1), least, 100*(counts[least]/expected-1))
+	return result(cfg, "Bytes", stat, ChiSquareP(stat, 255), detail)
+}
+
+// flips hashes random inputs with each of their bits flipped in turn.  It returns how many
+// times each output bit flipped for each input bit, and for each output bit, a bitset over
+// all the flipped inputs of whether it flipped.
+func flips(h HashFunc, cfg Config) (matrix [][]int, columns [][]uint64) {
+	inBits := cfg.InputSize * 8
+	samples := cfg.Samples * inBits
+	next := inputs(cfg)
+	matrix = make([][]int, inBits)
+
+	k := 0
+	for s := 0; s < cfg.Samples; s++ {
+		src := next()
+		base := h(src)
+		if columns == nil {
+			columns = make([][]uint64, len(base)*8)
+			for j := range columns {
+				columns[j] = make([]uint64, (samples+63)/64)
+			}
+			for i := range matrix {
+				matrix[i] = make([]int, len(base)*8)
+			}
+		}
+		for i := 0; i < inBits; i++ {
+			src[i/8] ^= 1 << uint(i%8)
+			out := h(src)
+			src[i/8] ^= 1 << uint(i%8)
+
+			for j := range columns {
+				if (out[j/8]^base[j/8])&(1<<uint(j%8)) != 0 {
+					matrix[i][j]++
+					columns[j][k/64] |= 1 << uint(k%64)
+				}
+			}
+			k++
+		}
+	}
+	return matrix, columns
+}
+
+// Avalanche tests the strict avalanche criterion: flipping any input bit flips each output
+// bit half the time.  The count of flips of every pair of input and output bits is compared
+// to half the samples with a chi-square test.
+func Avalanche(h HashFunc, cfg Config) Result {
+	matrix, _ := flips(h, cfg)
+	return avalanche(cfg, matrix)
+}
+
+// avalanche is Avalanche over the counts of flips returned by flips
+func avalanche(cfg Config, matrix [][]int) Result {
+	n := float64(cfg.Samples)
+
+	stat, flipped, cells := 0.0, 0.0, 0
+	worst, worstIn, worstOut := 0.5, 0, 0
+	for i, row := range matrix {
+		for j, c := range row {
+			d := float64(c) - n/2
+			stat += d * d / (n / 4)
+			flipped += float64(c)
+			cells++
+			if rate := float64(c) / n; math.Abs(rate-0.5) > math.Abs(worst-0.5) {
+				worst, worstIn, worstOut = rate, i, j
+			}
+		}
+	}
+	if cells == 0 {
+		return result(cfg, "Avalanche", 0, 0, "no bits to flip")
+	}
+	detail := fmt.Sprintf("flip rate %.4f, worst %.4f for input bit %d and output bit %d",
+		flipped/n/float64(cells), worst, worstIn, worstOut)
+	return result(cfg, "Avalanche", stat, ChiSquareP(stat, cells), detail)
+}
+
+// Independence tests the bit independence criterion: when an input bit is flipped, whether
+// one output bit flips is independent of whether any other does.  The statistic is the
+// largest z score of the correlation of the flips of any two output bits, and its p-value
+// is corrected for the number of pairs.
+func Independence(h HashFunc, cfg Config) Result {
+	_, columns := flips(h, cfg)
+	return independence(cfg, columns)
+}
+
+// independence is Independence over the bitsets of flips returned by flips
+func independence(cfg Config, columns [][]uint64) Result {
+	samples := float64(cfg.Samples * cfg.InputSize * 8)
+
+	ones := make([]float64, len(columns))
+	for j, col := range columns {
+		for _, w := range col {
+			ones[j] += float64(bits.OnesCount64(w))
+		}
+		if ones[j] == 0 || ones[j] == samples {
+			return result(cfg, "Independence", math.Inf(1), 0, fmt.Sprintf("output bit %d never changes", j))
+		}
+	}
+
+	worst, worstA, worstB := 0.0, 0, 0
+	for a := range columns {
+		for b := a + 1; b < len(columns); b++ {
+			both := 0
+			for w := range columns[a] {
+				both += bits.OnesCount64(columns[a][w] & columns[b][w])
+			}
+			// The phi coefficient of the 2x2 table of flips, scaled to a z score
+			phi := (samples*float64(both) - ones[a]*ones[b]) /
+				math.Sqrt(ones[a]*(samples-ones[a])*ones[b]*(samples-ones[b]))
+			if z := phi * math.Sqrt(samples); math.Abs(z) > math.Abs(worst) {
+				worst, worstA, worstB = z, a, b
+			}
+		}
+	}
+
+	pairs := float64(len(columns) * (len(columns) - 1) / 2)
+	p := math.Min(1, pairs*NormalP(worst))
+	detail := fmt.Sprintf("worst correlation %.4f between output bits %d and %d over %.0f pairs",
+		worst/math.Sqrt(samples), worstA, worstB, pairs)
+	return result(cfg, "Independence", worst, p, detail)
+}
+
+// Runs tests the bit stream of the hashes of the counters 0 to Samples-1, as 8 byte big
+// endian inputs, with the Wald-Wolfowitz runs test.  Too few runs means the bits cluster,
+// too many that they alternate.
+func Runs(h HashFunc, cfg Config) Result {
+	var ones, n, runs float64
+	last := -1
+	var src [8]byte
+	for i := 0; i < cfg.Samples; i++ {
+		binary.BigEndian.PutUint64(src[:], uint64(i))
+		for _, v := range h(src[:]) {
+			for j := 7; j >= 0; j-- {
+				bit := int(v>>uint(j)) & 1
+				ones += float64(bit)
+				n++
+				if bit != last {
+					runs++
+					last = bit
+				}
+			}
+		}
+	}
+
+	zeros := n - ones
+	if ones == 0 || zeros == 0 {
+		return result(cfg, "Runs", math.Inf(1), 0, fmt.Sprintf("all %.0f bits are the same", n))
+	}
+	mean := 2*ones*zeros/n + 1
+	variance := (mean - 1) * (mean - 2) / (n - 1)
+	z := (runs - mean) / math.Sqrt(variance)
+	detail := fmt.Sprintf("%.0f runs in %.0f bits, %.0f expected, %.4f ones", runs, n, mean, ones/n)
+	return result(cfg, "Runs", z, NormalP(z), detail)
+}
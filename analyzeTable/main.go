@@ -0,0 +1,60 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// analyzeTable generates the ByteMap for a seed and size with each of a list of Passes, and
+// compares their quality: the uniformity of the counts of the byte values, the correlation
+// of adjacent entries, the distinct values in cache lines and the cycle structure, e.g.
+//
+//	go run ./analyzeTable -bits 20 -passes 0,1,2,3,5,8
+//
+// Any number of passes may be compared, even those below MinPasses, as 0 passes gives the
+// unshuffled table for a baseline.  The tables are generated in memory and not cached, so
+// each needs the memory of the table.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	lxr "github.com/pegnet/LXRHash"
+	"github.com/pegnet/LXRHash/analysis"
+)
+
+func main() {
+	seed := flag.Uint64("seed", lxr.Seed, "seed of the ByteMap")
+	bits := flag.Uint64("bits", 20, "MapSizeBits of the ByteMap")
+	passes := flag.String("passes", "0,1,2,3,5,8", "comma separated Passes to compare")
+	verbose := flag.Bool("v", false, "print the full report of every table")
+	flag.Parse()
+
+	// Only the size is bounded, by what can be generated in memory
+	params := lxr.Params{Seed: *seed, MapSizeBits: *bits, HashSize: lxr.HashSize, Passes: lxr.MinPasses}
+	if err := params.Validate(); err != nil {
+		fail(err)
+	}
+
+	var list []uint64
+	for _, s := range strings.Split(*passes, ",") {
+		p, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			fail(fmt.Errorf("bad passes %q: %v", s, err))
+		}
+		list = append(list, p)
+	}
+
+	reports := analysis.ComparePasses(*seed, *bits, list, analysis.DefaultConfig)
+	if *verbose {
+		for _, r := range reports {
+			fmt.Println(r)
+		}
+	}
+	fmt.Print(analysis.FormatPasses(reports))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "analyzeTable:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,196 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Implementation identifies one of the hashing functions.  All implementations produce the
+// same results, but which one is fastest depends on the CPU.
+type Implementation int32
+
+const (
+	ImplHash             Implementation = iota + 1 // Hash, built on closures
+	ImplFlatHash                                   // FlatHash, built on methods
+	ImplHashParallel                               // HashParallel, interleaving the lanes of a batch
+	ImplFastHash                                   // FastHash, in assembly where available
+	ImplFastHashParallel                           // FastHashParallel, in assembly where available
+	ImplHashLanes                                  // HashLanes, prefetching the lookups of a batch
+)
+
+// Implementations lists every implementation considered by Calibrate
+var Implementations = []Implementation{ImplHash, ImplFlatHash, ImplHashParallel, ImplFastHash, ImplFastHashParallel, ImplHashLanes}
+
+// calibrationBatch is the number of nonces hashed per call while calibrating
+const calibrationBatch = 128
+
+func (i Implementation) String() string {
+	switch i {
+	case ImplHash:
+		return "Hash"
+	case ImplFlatHash:
+		return "FlatHash"
+	case ImplHashParallel:
+		return "HashParallel"
+	case ImplFastHash:
+		return "FastHash"
+	case ImplFastHashParallel:
+		return "FastHashParallel"
+	case ImplHashLanes:
+		return "HashLanes"
+	}
+	return fmt.Sprintf("Implementation(%d)", int32(i))
+}
+
+// BatchHasher hashes every item in the batch prefixed by the base, the same as HashParallel.
+// Every BatchHasher returned by Batch and Best accepts an empty batch, returning no hashes,
+// and items of different lengths, whichever implementation it dispatches to.
+type BatchHasher func(base []byte, batch [][]byte) [][]byte
+
+// Batch returns the given implementation as a BatchHasher
+func (lx LXRHash) Batch(impl Implementation) BatchHasher {
+	switch impl {
+	case ImplFlatHash:
+		return serialBatch(lx.FlatHash)
+	case ImplHashParallel:
+		return lanesBatch(lx.HashParallel)
+	case ImplFastHash:
+		return serialBatch(lx.FastHash)
+	case ImplFastHashParallel:
+		return lanesBatch(lx.FastHashParallel)
+	case ImplHashLanes:
+		return lanesBatch(lx.HashLanes)
+	}
+	return serialBatch(lx.Hash)
+}
+
+// lanesBatch wraps an implementation that steps the items of a batch together, and so needs
+// at least one item and items of the same length, into a BatchHasher.  The items are hashed
+// in groups of the same length.
+func lanesBatch(f func(base []byte, batch [][]byte) [][]byte) BatchHasher {
+	return func(base []byte, batch [][]byte) [][]byte {
+		ret := make([][]byte, len(batch))
+		if len(batch) == 0 {
+			return ret
+		}
+		same := true
+		for _, item := range batch {
+			same = same && len(item) == len(batch[0])
+		}
+		if same {
+			return f(base, batch)
+		}
+
+		groups := make(map[int][]int) // Positions in the batch of the items of each length
+		var lengths []int
+		for i, item := range batch {
+			if _, ok := groups[len(item)]; !ok {
+				lengths = append(lengths, len(item))
+			}
+			groups[len(item)] = append(groups[len(item)], i)
+		}
+		for _, length := range lengths {
+			group := make([][]byte, len(groups[length]))
+			for k, i := range groups[length] {
+				group[k] = batch[i]
+			}
+			for k, h := range f(base, group) {
+				ret[groups[length][k]] = h
+			}
+		}
+		return ret
+	}
+}
+
+// serialBatch wraps a single hash function into a BatchHasher
+func serialBatch(f func([]byte) []byte) BatchHasher {
+	return func(base []byte, batch [][]byte) [][]byte {
+		ret := make([][]byte, len(batch))
+		src := append([]byte{}, base...)
+		for i, item := range batch {
+			src = append(src[:len(base)], item...)
+			ret[i] = f(src)
+		}
+		return ret
+	}
+}
+
+// Calibrate times each implementation on the current machine, mining a batch of nonces
+// on a 32 byte base, and caches the fastest for Best.  Every implementation is timed twice
+// for half the given duration, first in the order of Implementations and then in reverse,
+// and the better run is kept, so that the order of the runs does not favour any of them.
+// Each implementation runs for the given duration in all, and Calibrate takes that times
+// the number of Implementations.
+func (lx *LXRHash) Calibrate(duration time.Duration) Implementation {
+	rates := make(map[Implementation]float64)
+	for round := 0; round < 2; round++ {
+		for _, impl := range calibrationOrder(round) {
+			if rate := lx.timeImplementation(impl, duration/2); rate > rates[impl] {
+				rates[impl] = rate
+			}
+		}
+	}
+
+	best := ImplHash
+	for _, impl := range Implementations {
+		if rates[impl] > rates[best] {
+			best = impl
+		}
+	}
+	lx.Log(fmt.Sprintf("Calibrated LXRHash implementations: %s is fastest at %.0f hps", best, rates[best]))
+
+	atomic.StoreInt32(&lx.best, int32(best))
+	return best
+}
+
+// calibrationOrder returns the order the implementations are timed in, which is reversed on
+// odd rounds
+func calibrationOrder(round int) []Implementation {
+	order := append([]Implementation{}, Implementations...)
+	if round%2 == 1 {
+		for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+			order[i], order[j] = order[j], order[i]
+		}
+	}
+	return order
+}
+
+// timeImplementation returns the number of hashes per second computed by impl
+func (lx *LXRHash) timeImplementation(impl Implementation, duration time.Duration) float64 {
+	f := lx.Batch(impl)
+	base := make([]byte, 32) // null base is ok
+	batch := make([][]byte, calibrationBatch)
+	for i := range batch {
+		batch[i] = make([]byte, 4)
+	}
+
+	var hashes uint64
+	start := time.Now()
+	for nonce := uint32(0); time.Since(start) < duration; {
+		for i := range batch {
+			binary.BigEndian.PutUint32(batch[i], nonce)
+			nonce++
+		}
+		f(base, batch)
+		hashes += calibrationBatch
+	}
+	return float64(hashes) / time.Since(start).Seconds()
+}
+
+// Implementation returns the implementation selected by Calibrate, or ImplHash if
+// Calibrate has not been called
+func (lx *LXRHash) Implementation() Implementation {
+	if best := Implementation(atomic.LoadInt32(&lx.best)); best != 0 {
+		return best
+	}
+	return ImplHash
+}
+
+// Best returns a BatchHasher that dispatches to the fastest implementation found by Calibrate
+func (lx *LXRHash) Best() BatchHasher {
+	return lx.Batch(lx.Implementation())
+}
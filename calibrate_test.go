@@ -0,0 +1,86 @@
+package lxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestLXRHash_Batch(t *testing.T) {
+	base := []byte("base of the batch")
+	batch := make([][]byte, 16)
+	for i := range batch {
+		batch[i] = make([]byte, 4)
+		binary.BigEndian.PutUint32(batch[i], uint32(i))
+	}
+
+	for _, impl := range Implementations {
+		res := lx.Batch(impl)(base, batch)
+		if len(res) != len(batch) {
+			t.Fatalf("%s returned %d results for %d items", impl, len(res), len(batch))
+		}
+		for i := range res {
+			want := lx.FlatHash(append(append([]byte{}, base...), batch[i]...))
+			if !bytes.Equal(res[i], want) {
+				t.Errorf("%s mismatch for item %d. got = %x, want = %x", impl, i, res[i], want)
+			}
+		}
+	}
+}
+
+func TestLXRHash_BatchMixed(t *testing.T) {
+	base := []byte("base of the batch")
+	batch := [][]byte{{1}, {}, {2, 3, 4}, {5}, {6, 7, 8, 9, 10, 11, 12, 13, 14}, {15, 16, 17}}
+
+	for _, impl := range Implementations {
+		if res := lx.Batch(impl)(base, nil); len(res) != 0 {
+			t.Errorf("%s returned %d results for an empty batch", impl, len(res))
+		}
+		res := lx.Batch(impl)(base, batch)
+		if len(res) != len(batch) {
+			t.Fatalf("%s returned %d results for %d items", impl, len(res), len(batch))
+		}
+		for i := range res {
+			want := lx.FlatHash(append(append([]byte{}, base...), batch[i]...))
+			if !bytes.Equal(res[i], want) {
+				t.Errorf("%s mismatch for item %d. got = %x, want = %x", impl, i, res[i], want)
+			}
+		}
+	}
+}
+
+func TestLXRHash_Calibrate(t *testing.T) {
+	l := lx
+	if impl := l.Implementation(); impl != ImplHash {
+		t.Errorf("uncalibrated implementation should be Hash, got %s", impl)
+	}
+
+	best := l.Calibrate(time.Millisecond * 100)
+	if impl := l.Implementation(); impl != best {
+		t.Errorf("calibration not cached. got = %s, want = %s", impl, best)
+	}
+
+	batch := [][]byte{[]byte("foo"), []byte("bar")}
+	res := l.Best()(nil, batch)
+	for i := range batch {
+		if want := lx.Hash(batch[i]); !bytes.Equal(res[i], want) {
+			t.Errorf("Best (%s) mismatch for %s. got = %x, want = %x", best, batch[i], res[i], want)
+		}
+	}
+}
+
+func TestCalibrationOrder(t *testing.T) {
+	first, second := calibrationOrder(0), calibrationOrder(1)
+	if len(first) != len(Implementations) || len(second) != len(Implementations) {
+		t.Fatalf("got %d and %d implementations, expected %d", len(first), len(second), len(Implementations))
+	}
+	for i, impl := range Implementations {
+		if first[i] != impl || second[len(second)-1-i] != impl {
+			t.Fatalf("rounds are not in order and reversed: %v and %v", first, second)
+		}
+	}
+	if Implementations[0] != ImplHash {
+		t.Error("calibrationOrder changed Implementations")
+	}
+}
@@ -2,6 +2,8 @@
 // Licensed under the MIT License. See LICENSE file in the project root for full license information.
 package lxr
 
+import "math/bits"
+
 // Default Seed
 const (
 	Seed        = uint64(0xFAFAECECFAFAECEC) // The seed defines a "hash space".
@@ -9,3 +11,16 @@ const (
 	Passes      = uint64(5)                  // Default number of shuffles of the tables
 	HashSize    = uint64(256)                // Default hash size.
 )
+
+// Bounds of the parameters, checked by Params.Validate
+const (
+	MinMapSizeBits = uint64(8)    // Smallest table, holding every byte value once
+	MaxMapSizeBits = uint64(34)   // Largest table, 16 GB.  Only 30 bits can be addressed on 32 bit platforms
+	MinHashSize    = uint64(8)    // Smallest hash size in bits
+	MaxHashSize    = uint64(8192) // Largest hash size in bits
+	MinPasses      = uint64(1)    // Fewest shuffles of the tables
+)
+
+// maxAddressableBits is the largest MapSizeBits for which the ByteMap can be held in memory,
+// as its length must fit in an int.  It is 30 bits on 32 bit platforms.
+const maxAddressableBits = uint64(bits.UintSize - 2)
@@ -0,0 +1,78 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// Package debug serves the state of the LXRHash tables for debug endpoints.  It is kept out
+// of the lxr package so that miners do not link net/http.
+package debug
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+// registryJSON is the JSON served by RegistryHandler
+type registryJSON struct {
+	Budget    uint64         `json:"budget"`    // Memory budget in bytes, or zero if there is none
+	Resident  uint64         `json:"resident"`  // Bytes of the tables in the registry
+	Evictions uint64         `json:"evictions"` // Number of tables evicted to stay within the budget
+	Instances []instanceJSON `json:"instances"`
+}
+
+// instanceJSON is a table in the JSON served by RegistryHandler
+type instanceJSON struct {
+	Seed        string    `json:"seed"` // Seed as 16 hex digits
+	MapSizeBits uint64    `json:"mapSizeBits"`
+	HashSize    uint64    `json:"hashSize"` // Number of bits in the hash
+	Passes      uint64    `json:"passes"`
+	Refs        int       `json:"refs"`
+	Bytes       uint64    `json:"bytes"`
+	Loading     bool      `json:"loading"`
+	Acquires    uint64    `json:"acquires"`
+	LastUsed    time.Time `json:"lastUsed"`
+	Digest      string    `json:"digest,omitempty"` // SHA-256 of the ByteMap in hex
+}
+
+// RegistryHandler returns an http.Handler that serves the statistics of the singleton
+// registry of the lxr package, and the Instances in it, as JSON.  It is meant for debug endpoints, and is not
+// registered anywhere unless the caller does so.
+func RegistryHandler() http.Handler {
+	return http.HandlerFunc(serveRegistry)
+}
+
+func serveRegistry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stats, list := lxr.Snapshot()
+	reg := registryJSON{Budget: stats.Budget, Resident: stats.Resident, Evictions: stats.Evictions, Instances: []instanceJSON{}}
+	for _, in := range list {
+		i := instanceJSON{
+			Seed:        fmt.Sprintf("%016x", in.Params.Seed),
+			MapSizeBits: in.Params.MapSizeBits,
+			HashSize:    in.Params.HashSize,
+			Passes:      in.Params.Passes,
+			Refs:        in.Refs,
+			Bytes:       in.Bytes,
+			Loading:     in.Loading,
+			Acquires:    in.Acquires,
+			LastUsed:    in.LastUsed,
+		}
+		if !in.Loading {
+			i.Digest = hex.EncodeToString(in.Digest[:])
+		}
+		reg.Instances = append(reg.Instances, i)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(reg)
+}
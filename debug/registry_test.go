@@ -0,0 +1,63 @@
+package debug
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+func TestRegistryHandler(t *testing.T) {
+	p := lxr.Params{Seed: 0xdeb06, MapSizeBits: 8, HashSize: lxr.HashSize, Passes: lxr.Passes}
+	h, err := lxr.Acquire(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Release()
+	digest := h.LXRHash().Digest()
+
+	var found bool
+	for _, in := range lxr.Instances() {
+		if in.Params == p {
+			found = true
+			if in.Refs != 1 || in.Bytes != 256 || in.Loading || in.Digest != digest {
+				t.Errorf("unexpected instance %+v", in)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("%+v missing from the instances", p)
+	}
+
+	rec := httptest.NewRecorder()
+	RegistryHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/lxrhash", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var reg registryJSON
+	if err := json.Unmarshal(rec.Body.Bytes(), &reg); err != nil {
+		t.Fatal(err)
+	}
+	found = false
+	for _, in := range reg.Instances {
+		if in.Seed == "00000000000deb06" && in.MapSizeBits == 8 {
+			found = true
+			if in.Refs != 1 || in.Digest != hex.EncodeToString(digest[:]) {
+				t.Errorf("unexpected instance %+v", in)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("%+v missing from %s", p, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	RegistryHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/lxrhash", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected %d for a POST, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
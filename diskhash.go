@@ -0,0 +1,191 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// DiskPageSize is the size of the pages of the ByteMap cached by a DiskHash
+const DiskPageSize = 4096
+
+// DiskHash computes the same hashes as an LXRHash, but reads the ByteMap from the cached
+// table file as it is needed rather than holding it in memory.  Tables too large to address
+// in memory on 32 bit platforms can be used, provided they were cached elsewhere.  Only a small cache of the
+// most recently used pages of the table is kept.  Every lookup that misses the cache is a
+// read of the file, so hashing is far slower, and is meant for validating a few hashes
+// on machines that cannot spare the memory for the table.
+type DiskHash struct {
+	MapSize     uint64 // Size of the translation table
+	MapSizeBits uint64 // Size of the ByteMap in Bits
+	Passes      uint64 // Passes to generate the rand table
+	Seed        uint64 // An arbitrary number used to create the tables.
+	HashSize    uint64 // Number of bytes in the hash
+
+	file   *os.File
+	offset int64 // Offset of the ByteMap in file
+
+	mtx      sync.Mutex
+	capacity int                      // Maximum number of pages cached
+	pages    map[uint64]*list.Element // Cached pages by page number
+	lru      *list.List               // Cached pages, most recently used first
+	hits     uint64
+	misses   uint64
+	err      error // First read error of the current hash
+}
+
+// diskPage is a page of the ByteMap cached by a DiskHash
+type diskPage struct {
+	page uint64
+	data []byte
+}
+
+// NewDiskHash opens the cached table for the given Params, with a cache of cacheSize bytes.
+// If the table has not been cached yet, it is generated and written like Init does, which
+// needs the memory for the table while it is generated.
+func NewDiskHash(p Params, cacheSize int) (*DiskHash, error) {
+	if err := p.validate(false); err != nil {
+		return nil, err
+	}
+
+	lx := &LXRHash{Seed: p.Seed, MapSize: uint64(1) << p.MapSizeBits, MapSizeBits: p.MapSizeBits, Passes: p.Passes}
+	filename, legacy, err := lx.tableFilename()
+	if err != nil {
+		return nil, err
+	}
+	if !lx.cached(filename) && lx.cached(legacy) {
+		filename = legacy
+	}
+	if !lx.cached(filename) {
+		// The table is read from disk, so it may be larger than could be held in memory,
+		// but then it cannot be generated here
+		if p.MapSizeBits > maxAddressableBits {
+			return nil, fmt.Errorf("table %s is not cached, and cannot be generated on this platform", filename)
+		}
+		err := lx.readTable(context.Background())
+		lx.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var offset int64
+	switch info.Size() {
+	case int64(lx.MapSize): // Tables written before headers were added
+	case tableHeaderSize + int64(lx.MapSize):
+		h := make([]byte, tableHeaderSize)
+		if _, err := f.ReadAt(h, 0); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if _, _, _, err := lx.checkTableHeader(h); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("table %s: %v", filename, err)
+		}
+		offset = tableHeaderSize
+	default:
+		f.Close()
+		return nil, fmt.Errorf("table %s is %d bytes, expected %d", filename, info.Size(), tableHeaderSize+lx.MapSize)
+	}
+
+	capacity := cacheSize / DiskPageSize
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &DiskHash{
+		MapSize:     lx.MapSize,
+		MapSizeBits: lx.MapSizeBits,
+		Passes:      lx.Passes,
+		Seed:        lx.Seed,
+		HashSize:    (p.HashSize + 7) / 8,
+		file:        f,
+		offset:      offset,
+		capacity:    capacity,
+		pages:       make(map[uint64]*list.Element),
+		lru:         list.New(),
+	}, nil
+}
+
+// cached checks the file holds a table of the size of the ByteMap, with or without a header
+func (lx *LXRHash) cached(filename string) bool {
+	info, err := os.Stat(filename)
+	return err == nil && (info.Size() == int64(lx.MapSize) || info.Size() == tableHeaderSize+int64(lx.MapSize))
+}
+
+// Close closes the table file
+func (d *DiskHash) Close() error {
+	return d.file.Close()
+}
+
+// CacheStats returns the number of lookups that hit and missed the page cache
+func (d *DiskHash) CacheStats() (hits, misses uint64) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.hits, d.misses
+}
+
+// lookup returns the byte of the ByteMap at index i, reading its page if it is not cached.
+// A read error is kept in d.err, and the lookup returns zero.
+func (d *DiskHash) lookup(i uint64) byte {
+	page := i / DiskPageSize
+	if e, ok := d.pages[page]; ok {
+		d.hits++
+		d.lru.MoveToFront(e)
+		return e.Value.(*diskPage).data[i%DiskPageSize]
+	}
+	d.misses++
+
+	// Reuse the least recently used page once the cache is full
+	var p *diskPage
+	if d.lru.Len() >= d.capacity {
+		e := d.lru.Back()
+		p = e.Value.(*diskPage)
+		delete(d.pages, p.page)
+		d.lru.Remove(e)
+	} else {
+		p = &diskPage{data: make([]byte, DiskPageSize)}
+	}
+
+	length := uint64(DiskPageSize)
+	if start := page * DiskPageSize; d.MapSize-start < length {
+		length = d.MapSize - start // Tables smaller than a page
+	}
+	n, err := d.file.ReadAt(p.data[:length], d.offset+int64(page*DiskPageSize))
+	if uint64(n) != length {
+		if d.err == nil {
+			d.err = fmt.Errorf("reading page %d of the ByteMap: %v", page, err)
+		}
+		return 0
+	}
+
+	p.page = page
+	d.pages[page] = d.lru.PushFront(p)
+	return p.data[i%DiskPageSize]
+}
+
+// Hash takes the arbitrary input and returns the resulting hash of length HashSize, the same
+// as LXRHash.Hash.  An error is returned if the table could not be read.
+func (d *DiskHash) Hash(src []byte) ([]byte, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.err = nil
+
+	bytes := hashLookup(d.Seed, d.MapSize, d.HashSize, [][]byte{src}, func(_ Phase, index uint64) byte { return d.lookup(index) })
+	if d.err != nil {
+		return nil, d.err
+	}
+	return bytes, nil
+}
@@ -0,0 +1,102 @@
+package lxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDiskHash_Hash(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	for _, l := range fastParams() {
+		p := Params{Seed: l.Seed, MapSizeBits: l.MapSizeBits, HashSize: l.HashSize * 8, Passes: l.Passes}
+		d, err := NewDiskHash(p, 16*DiskPageSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, length := range []int{0, 1, 32, 100} {
+			src := make([]byte, length)
+			r.Read(src)
+
+			got, err := d.Hash(src)
+			if err != nil {
+				t.Fatalf("%s: %v", paramName(l), err)
+			}
+			// DiskHash shares the body of Hash, so compare with the separate steps of FlatHash
+			if want := l.FlatHash(src); !bytes.Equal(got, want) {
+				t.Errorf("%s: mismatch for %x. got = %x, want = %x", paramName(l), src, got, want)
+			}
+		}
+
+		if hits, misses := d.CacheStats(); hits == 0 || misses == 0 {
+			t.Errorf("%s: unexpected cache stats, %d hits and %d misses", paramName(l), hits, misses)
+		}
+		if err := d.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDiskHash_Error(t *testing.T) {
+	d, err := NewDiskHash(Params{Seed: Seed, MapSizeBits: 16, HashSize: HashSize, Passes: Passes}, DiskPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	if _, err := d.Hash([]byte("closed")); err == nil {
+		t.Error("expected an error hashing with a closed table")
+	}
+}
+
+// BenchmarkDiskHash compares hashing the oprhash on the 30 bit table from disk, with
+// different cache sizes, against the in memory hash
+func BenchmarkDiskHash(b *testing.B) {
+	src := append(append([]byte{}, oprhash...), 0, 0, 0, 0)
+	b.Run("memory", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			binary.BigEndian.PutUint32(src[len(oprhash):], uint32(i))
+			lx.Hash(src)
+		}
+	})
+
+	p := Params{Seed: lx.Seed, MapSizeBits: lx.MapSizeBits, HashSize: lx.HashSize * 8, Passes: lx.Passes}
+	for _, cacheSize := range []int{64 << 10, 1 << 20, 16 << 20} {
+		b.Run(fmt.Sprintf("disk/%dKB", cacheSize>>10), func(b *testing.B) {
+			d, err := NewDiskHash(p, cacheSize)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer d.Close()
+			for i := 0; i < b.N; i++ {
+				binary.BigEndian.PutUint32(src[len(oprhash):], uint32(i))
+				if _, err := d.Hash(src); err != nil {
+					b.Fatal(err)
+				}
+			}
+			hits, misses := d.CacheStats()
+			b.ReportMetric(float64(hits)/float64(hits+misses), "hit-ratio")
+			b.ReportMetric(float64(cacheSize), "cache-bytes")
+		})
+	}
+}
+
+func TestDiskHash_Header(t *testing.T) {
+	// Tables cached since headers were added are read past the header
+	l := new(LXRHash)
+	l.Init(0xd15c, 10, HashSize, Passes)
+	d, err := NewDiskHash(Params{Seed: 0xd15c, MapSizeBits: 10, HashSize: HashSize, Passes: Passes}, DiskPageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if d.offset != tableHeaderSize {
+		t.Errorf("expected the table at offset %d, got %d", tableHeaderSize, d.offset)
+	}
+	src := []byte("past the header")
+	if got, err := d.Hash(src); err != nil || !bytes.Equal(got, l.Hash(src)) {
+		t.Errorf("mismatch: got = %x (%v), want = %x", got, err, l.Hash(src))
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+// asmState is the state of one hash carried between the passes of FastHash.
+// The layout is relied upon by hash_amd64.s.
+type asmState struct {
+	as, s1, s2, s3 uint64
+}
+
+// lane is the state of one hash of FastHashParallel, along with the byte being stepped.
+// The layout is relied upon by hash_amd64.s.
+type lane struct {
+	asmState
+	v2 uint64
+}
+
+// fastSafe reports if the ByteMap and HashSize are safe to use with the passes, which
+// do not check the bounds of the ByteMap
+func (lx LXRHash) fastSafe() bool {
+	return lx.HashSize > 0 && lx.MapSize > 0 && uint64(len(lx.ByteMap)) == lx.MapSize
+}
+
+// FastHash takes the arbitrary input and returns the resulting hash of length HashSize.
+// On amd64 the hash is computed in assembly, keeping the state in registers and avoiding
+// bounds checks.  Elsewhere, or when built with the purego tag, it falls back to Go.
+// The results are identical to Hash.
+func (lx LXRHash) FastHash(src []byte) []byte {
+	if !lx.fastSafe() {
+		return lx.FlatHash(src)
+	}
+	mk := lx.MapSize - 1
+	st := asmState{as: lx.Seed}
+	hs := make([]uint64, lx.HashSize)
+
+	fastPass(lx.ByteMap, mk, &st, hs, src)
+	stepPass(lx.ByteMap, mk, &st, hs, src)
+
+	bytes := make([]byte, lx.HashSize)
+	reducePass(lx.ByteMap, mk, &st, hs, bytes)
+	return bytes
+}
+
+// FastHashParallel takes the arbitrary input and returns the resulting hash of length HashSize.
+// The base is prefixed to all items in the batch, and all items must be the same length.
+// Like HashParallel, each line of the step is applied to every lane of the batch in turn,
+// so the lookups of the lanes are in flight together.  On amd64 the steps are computed in
+// assembly.  The results are identical to HashParallel.
+func (lx LXRHash) FastHashParallel(base []byte, batch [][]byte) [][]byte {
+	if !lx.fastSafe() || len(batch) == 0 {
+		return lx.HashParallel(base, batch)
+	}
+	mk := lx.MapSize - 1
+	size := lx.HashSize
+
+	lanes := make([]lane, len(batch))
+	hs := make([]uint64, uint64(len(batch))*size)
+	src := make([]byte, len(base)+len(batch[0]))
+	copy(src, base)
+	for j := range lanes {
+		copy(src[len(base):], batch[j])
+		lanes[j].as = lx.Seed
+		fastPass(lx.ByteMap, mk, &lanes[j].asmState, hs[uint64(j)*size:uint64(j+1)*size], src)
+	}
+
+	idx := uint64(0)
+	// Actual work to compute the hash
+	for i := range src {
+		if idx >= size { // Use an if to avoid modulo math
+			idx = 0
+		}
+		for j := range lanes {
+			if i < len(base) {
+				lanes[j].v2 = uint64(base[i])
+			} else {
+				lanes[j].v2 = uint64(batch[j][i-len(base)])
+			}
+		}
+		stepLanes(lx.ByteMap, mk, lanes, hs, size, idx)
+		idx++
+	}
+
+	ret := make([][]byte, len(batch))
+	for j := range ret {
+		ret[j] = make([]byte, size)
+	}
+
+	// Reduction pass
+	for i := int64(size - 1); i >= 0; i-- {
+		for j := range lanes {
+			lanes[j].v2 = hs[uint64(j)*size+uint64(i)]
+		}
+		stepLanes(lx.ByteMap, mk, lanes, hs, size, uint64(i)) // Step the hash functions and then
+		for j := range lanes {
+			ret[j][i] = lx.ByteMap[lanes[j].as&mk] ^ lx.ByteMap[hs[uint64(j)*size+uint64(i)]&mk] // Xor two resulting sequences
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,117 @@
+package lxr
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// fastParams returns an instance for every combination of the parameters the
+// differential tests run against
+func fastParams() []LXRHash {
+	var ret []LXRHash
+	for _, seed := range []uint64{Seed, 0x0123456789abcdef} {
+		for _, bits := range []uint64{8, 10, 13, 16} {
+			for _, passes := range []uint64{1, Passes} {
+				l := new(LXRHash)
+				l.Init(seed, bits, HashSize, passes)
+				for _, size := range []uint64{8, 64, 136, 256, 512} {
+					c := *l
+					c.HashSize = size / 8
+					ret = append(ret, c)
+				}
+			}
+		}
+	}
+	return append(ret, lx)
+}
+
+// distinctParams are the fastParams with hashes of at least 8 bytes, for the tests that two
+// hashes differ.  Shorter hashes collide too often by chance to be told apart.
+func distinctParams() []LXRHash {
+	var ret []LXRHash
+	for _, l := range fastParams() {
+		if l.HashSize >= 8 {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+func paramName(l LXRHash) string {
+	return fmt.Sprintf("seed-%x-bits-%d-passes-%d-size-%d", l.Seed, l.MapSizeBits, l.Passes, l.HashSize*8)
+}
+
+func TestLXRHash_FastHash(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, l := range fastParams() {
+		for _, length := range []int{0, 1, 7, 31, 32, 33, 64, 100, 200} {
+			src := make([]byte, length)
+			r.Read(src)
+
+			if got, want := l.FastHash(src), l.FlatHash(src); !bytes.Equal(got, want) {
+				t.Errorf("%s: mismatch for %x. got = %x, want = %x", paramName(l), src, got, want)
+			}
+		}
+	}
+}
+
+func TestLXRHash_FastHashParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, l := range fastParams() {
+		base := make([]byte, r.Intn(40))
+		r.Read(base)
+
+		for _, size := range []int{1, 2, 7} {
+			batch := make([][]byte, size)
+			for i := range batch {
+				batch[i] = make([]byte, 4)
+				r.Read(batch[i])
+			}
+
+			got := l.FastHashParallel(base, batch)
+			want := l.HashParallel(base, batch)
+			for i := range batch {
+				flat := l.FlatHash(append(append([]byte{}, base...), batch[i]...))
+				if !bytes.Equal(got[i], flat) {
+					t.Errorf("%s: mismatch for item %d. got = %x, want = %x", paramName(l), i, got[i], flat)
+				}
+				if !bytes.Equal(got[i], want[i]) {
+					t.Errorf("%s: HashParallel mismatch for item %d. got = %x, want = %x", paramName(l), i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkFastHash(b *testing.B) {
+	b.Run("flat hash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lx.FlatHash(append(oprhash, byte(i), byte(i>>8), byte(i>>16)))
+		}
+	})
+	b.Run("FastHash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			lx.FastHash(append(oprhash, byte(i), byte(i>>8), byte(i>>16)))
+		}
+	})
+	b.Run("HashParallel", func(b *testing.B) {
+		batch := make([][]byte, 128)
+		for n := 0; n < b.N; n += len(batch) {
+			for i := range batch {
+				batch[i] = []byte{byte(n + i), byte((n + i) >> 8), byte((n + i) >> 16)}
+			}
+			lx.HashParallel(oprhash, batch)
+		}
+	})
+	b.Run("FastHashParallel", func(b *testing.B) {
+		batch := make([][]byte, 128)
+		for n := 0; n < b.N; n += len(batch) {
+			for i := range batch {
+				batch[i] = []byte{byte(n + i), byte((n + i) >> 8), byte((n + i) >> 16)}
+			}
+			lx.FastHashParallel(oprhash, batch)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+//go:build go1.18
+// +build go1.18
+
+package lxr
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+// fuzzMaxInput limits the inputs of the fuzz targets so each run stays fast
+const fuzzMaxInput = 512
+
+var fuzzMtx sync.Mutex
+var fuzzTables = make(map[uint64]*LXRHash)
+
+// fuzzHash returns an LXRHash for the fuzzed parameters, with a map of 8 to 16 bits and a
+// hash of 1 to 64 bytes
+func fuzzHash(bits, size uint8) LXRHash {
+	fuzzMtx.Lock()
+	defer fuzzMtx.Unlock()
+
+	mapSizeBits := 8 + uint64(bits)%9
+	l, ok := fuzzTables[mapSizeBits]
+	if !ok {
+		l = new(LXRHash)
+		l.Init(Seed, mapSizeBits, HashSize, Passes)
+		fuzzTables[mapSizeBits] = l
+	}
+	c := *l
+	c.HashSize = 1 + uint64(size)%64
+	return c
+}
+
+// FuzzHashEquivalence requires every hashing path to produce the same hash.  The data is
+// hashed whole after the base, and is also split into a batch of equal length items.
+func FuzzHashEquivalence(f *testing.F) {
+	f.Add(uint8(0), uint8(31), []byte{}, []byte{}, uint8(3))
+	f.Add(uint8(2), uint8(7), []byte("base"), []byte("nonce data"), uint8(1))
+	f.Add(uint8(8), uint8(63), bytes.Repeat([]byte{0xa5}, 32), []byte{0, 0, 0, 1, 0, 0, 0, 2}, uint8(3))
+
+	f.Fuzz(func(t *testing.T, bits, size uint8, base, data []byte, itemLen uint8) {
+		if len(base) > fuzzMaxInput {
+			base = base[:fuzzMaxInput]
+		}
+		if len(data) > fuzzMaxInput {
+			data = data[:fuzzMaxInput]
+		}
+		l := fuzzHash(bits, size)
+
+		src := append(append([]byte{}, base...), data...)
+		want := l.Hash(src)
+		if uint64(len(want)) != l.HashSize {
+			t.Fatalf("hash is %d bytes, expected %d", len(want), l.HashSize)
+		}
+		flat := l.FlatHash(src)
+		if !bytes.Equal(flat, want) {
+			t.Errorf("FlatHash = %x, Hash = %x", flat, want)
+		}
+		if got := l.FastHash(src); !bytes.Equal(got, want) {
+			t.Errorf("FastHash = %x, Hash = %x", got, want)
+		}
+		// Hash dispatches to Hash256, so check it against the generic reduction of FlatHash
+		if l.HashSize == 32 {
+			if got := l.Hash256(src); !bytes.Equal(got[:], flat) {
+				t.Errorf("Hash256 = %x, FlatHash = %x", got, flat)
+			}
+		}
+		if got := l.HashParallel(base, [][]byte{data}); !bytes.Equal(got[0], want) {
+			t.Errorf("HashParallel = %x, Hash = %x", got[0], want)
+		}
+
+		// Split the data into a batch of items of the same length
+		n := 1 + int(itemLen)%16
+		var batch [][]byte
+		for i := 0; i+n <= len(data); i += n {
+			batch = append(batch, data[i:i+n])
+		}
+		if len(batch) == 0 {
+			return
+		}
+		wants := make([][]byte, len(batch))
+		for i, item := range batch {
+			wants[i] = l.Hash(append(append([]byte{}, base...), item...))
+		}
+		engines := map[string]BatchHasher{
+			"HashParallel":     l.HashParallel,
+			"FastHashParallel": l.FastHashParallel,
+			"HashLanes":        l.HashLanes,
+		}
+		for _, impl := range Implementations {
+			engines["Batch "+impl.String()] = l.Batch(impl)
+		}
+		for name, engine := range engines {
+			got := engine(base, batch)
+			for i := range batch {
+				if !bytes.Equal(got[i], wants[i]) {
+					t.Errorf("%s item %d = %x, Hash = %x", name, i, got[i], wants[i])
+				}
+			}
+		}
+	})
+}
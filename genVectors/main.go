@@ -0,0 +1,171 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// genVectors extends the test vector corpus of the vectors package.
+//
+// The existing vectors are checked against this implementation first, and the corpus is
+// left untouched if any of them fail.  Then, for every combination of the given seeds, map
+// sizes, hash sizes and passes, vectors are added for inputs that are empty, a single byte,
+// and shorter than, equal to and longer than the hash, and keyed vectors for keys of the
+// given lengths and personalized vectors for the given tags, with inputs that are empty, as
+// long as and longer than the hash.  Vectors already in the corpus are not duplicated.
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	lxr "github.com/pegnet/LXRHash"
+	"github.com/pegnet/LXRHash/vectors"
+)
+
+const description = "LXRHash test vectors.  Each vector is the hash of the input by an LXRHash built with " +
+	"the given seed (16 hex digits), mapSizeBits, hashSize (in bits) and passes.  Input and output are hex.  " +
+	"Vectors of mode \"keyed\" are the KeyedHash of the input with the key, and vectors of mode \"personalized\" " +
+	"the PersonalizedHash of the input with the tag, in hex."
+
+func main() {
+	file := flag.String("o", "vectors/testdata/vectors.json", "corpus to extend")
+	seeds := flag.String("seeds", fmt.Sprintf("%x,123456789abcdef", lxr.Seed), "comma separated seeds in hex")
+	bits := flag.String("bits", "8,10,16,20", "comma separated MapSizeBits")
+	sizes := flag.String("sizes", "64,256,512", "comma separated HashSizes in bits")
+	passes := flag.String("passes", fmt.Sprint(lxr.Passes), "comma separated Passes")
+	keys := flag.String("keys", "0,16,65", "comma separated key lengths in bytes for keyed vectors")
+	tags := flag.String("tags", ",pow,merkle node", "comma separated tags for personalized vectors")
+	flag.Parse()
+
+	corpus, err := vectors.ReadFile(*file)
+	if os.IsNotExist(err) {
+		corpus = &vectors.Corpus{Version: vectors.Version}
+	} else if err != nil {
+		fail(err)
+	}
+	corpus.Version = vectors.Version
+	corpus.Description = description
+
+	hashes := make(map[lxr.Params]*lxr.LXRHash)
+	hasher := func(p lxr.Params) *lxr.LXRHash {
+		if lx, ok := hashes[p]; ok {
+			return lx
+		}
+		lx := new(lxr.LXRHash)
+		lx.Verbose(true)
+		lx.Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes)
+		hashes[p] = lx
+		return lx
+	}
+
+	// Never change the hashes of a published corpus
+	known := make(map[vectors.Vector]bool)
+	for _, v := range corpus.Vectors {
+		p, err := v.Params()
+		if err != nil {
+			fail(err)
+		}
+		input, output, err := v.Decode()
+		if err != nil {
+			fail(err)
+		}
+		got, err := v.Hash(hasher(p), input)
+		if err != nil {
+			fail(err)
+		}
+		if !bytes.Equal(got, output) {
+			fail(fmt.Errorf("vector %+v hashes to %x", v, got))
+		}
+		known[v] = true
+	}
+
+	added := 0
+	for _, seed := range parse(*seeds, 16) {
+		for _, b := range parse(*bits, 10) {
+			for _, pass := range parse(*passes, 10) {
+				for _, size := range parse(*sizes, 10) {
+					lx := hasher(lxr.Params{Seed: seed, MapSizeBits: b, HashSize: size, Passes: pass})
+					var vs []vectors.Vector
+					for _, length := range lengths(lx.HashSize) {
+						vs = append(vs, vectors.New(lx, input(length)))
+					}
+					for _, keyLength := range parse(*keys, 10) {
+						for _, length := range []uint64{0, lx.HashSize, 2*lx.HashSize + 5} {
+							vs = append(vs, vectors.NewKeyed(lx, key(int(keyLength)), input(int(length))))
+						}
+					}
+					for _, tag := range strings.Split(*tags, ",") {
+						for _, length := range []uint64{0, lx.HashSize, 2*lx.HashSize + 5} {
+							vs = append(vs, vectors.NewPersonalized(lx, []byte(tag), input(int(length))))
+						}
+					}
+					for _, v := range vs {
+						if !known[v] {
+							known[v] = true
+							corpus.Vectors = append(corpus.Vectors, v)
+							added++
+						}
+					}
+				}
+			}
+		}
+	}
+	corpus.Sort()
+
+	var buf bytes.Buffer
+	if err := corpus.Write(&buf); err != nil {
+		fail(err)
+	}
+	if err := ioutil.WriteFile(*file, buf.Bytes(), 0644); err != nil {
+		fail(err)
+	}
+	fmt.Printf("Checked %d vectors and added %d to %s\n", len(corpus.Vectors)-added, added, *file)
+}
+
+// lengths returns the input lengths covered for a hash of size bytes
+func lengths(size uint64) []int {
+	h := int(size)
+	return []int{0, 1, h / 2, h - 1, h, h + 1, 2*h + 5}
+}
+
+// input returns length bytes of input, taken from a SHA-256 chain so it is reproducible
+func input(length int) []byte {
+	return chain(fmt.Sprintf("LXRHash test vector %d", length), length)
+}
+
+// key returns a key of length bytes, taken from a SHA-256 chain so it is reproducible
+func key(length int) []byte {
+	return chain(fmt.Sprintf("LXRHash test key %d", length), length)
+}
+
+// chain returns the first length bytes of the SHA-256 chain starting from the hash of label
+func chain(label string, length int) []byte {
+	var ret []byte
+	block := sha256.Sum256([]byte(label))
+	for len(ret) < length {
+		ret = append(ret, block[:]...)
+		block = sha256.Sum256(block[:])
+	}
+	return ret[:length]
+}
+
+// parse parses a comma separated list of numbers in the given base
+func parse(list string, base int) []uint64 {
+	var ret []uint64
+	for _, s := range strings.Split(list, ",") {
+		v, err := strconv.ParseUint(strings.TrimSpace(s), base, 64)
+		if err != nil {
+			fail(fmt.Errorf("bad value %q: %v", s, err))
+		}
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "genVectors:", err)
+	os.Exit(1)
+}
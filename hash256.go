@@ -0,0 +1,91 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+// Hash256 takes the arbitrary input and returns the resulting 256 bit hash.  The result is the
+// same as Hash with a HashSize of 32 bytes, which is the default, but the intermediate results
+// are kept in a fixed size array so indexing them needs neither wrapping nor bounds checks.
+// Hash calls Hash256 automatically when HashSize is 32.
+func (lx LXRHash) Hash256(src []byte) [32]byte {
+	// Keep the byte intermediate results as int64 values until reduced.
+	var hs [32]uint64
+	// as accumulates the state as we walk through applying the source data through the lookup map
+	// and combine it with the state we are building up.
+	var as = lx.Seed
+	// We keep a series of states, and roll them along through each byte of source processed.
+	var s1, s2, s3 uint64
+	// Since MapSize is specified in bits, the index mask is the size-1
+	mk := lx.MapSize - 1
+
+	// Fast spin to prevent caching state
+	for i, v2 := range src {
+		as, s1, s2, s3 = lx.fastStep256(uint64(v2), as, s1, s2, s3, &hs[i&31])
+	}
+
+	// Actual work to compute the hash
+	for i, v2 := range src {
+		as, s1, s2, s3 = lx.step256(as, s1, s2, s3, uint64(v2), &hs[i&31], mk)
+	}
+
+	// Reduction pass
+	var bytes [32]byte
+	for i := 31; i >= 0; i-- {
+		as, s1, s2, s3 = lx.step256(as, s1, s2, s3, hs[i], &hs[i], mk)
+		bytes[i] = lx.ByteMap[as&mk] ^ lx.ByteMap[hs[i]&mk] // Xor two resulting sequences
+	}
+
+	// Return the resulting hash
+	return bytes
+}
+
+// fastStep256 is fastStepf for Hash256, where h points to hs[idx]
+func (lx LXRHash) fastStep256(v2, as, s1, s2, s3 uint64, h *uint64) (uint64, uint64, uint64, uint64) {
+	b := uint64(lx.ByteMap[(as^v2)&(lx.MapSize-1)])
+	as = as<<7 ^ as>>5 ^ v2<<20 ^ v2<<16 ^ v2 ^ b<<20 ^ b<<12 ^ b<<4
+	s1 = s1<<9 ^ s1>>3 ^ *h
+	*h = s1 ^ as
+	s1, s2, s3 = s3, s1, s2
+	return as, s1, s2, s3
+}
+
+// step256 is stepf for Hash256, where h points to hs[idx]
+func (lx LXRHash) step256(as, s1, s2, s3, v2 uint64, h *uint64, mk uint64) (uint64, uint64, uint64, uint64) {
+	s1 = s1<<9 ^ s1>>1 ^ as ^ uint64(lx.ByteMap[(as>>5^v2)&mk])<<3
+	s1 = s1<<5 ^ s1>>3 ^ uint64(lx.ByteMap[(s1^v2)&mk])<<7
+	s1 = s1<<7 ^ s1>>7 ^ uint64(lx.ByteMap[(as^s1>>7)&mk])<<5
+	s1 = s1<<11 ^ s1>>5 ^ uint64(lx.ByteMap[(v2^as>>11^s1)&mk])<<27
+
+	*h = s1 ^ as ^ *h<<7 ^ *h>>13
+
+	as = as<<17 ^ as>>5 ^ s1 ^ uint64(lx.ByteMap[(as^s1>>27^v2)&mk])<<3
+	as = as<<13 ^ as>>3 ^ uint64(lx.ByteMap[(as^s1)&mk])<<7
+	as = as<<15 ^ as>>7 ^ uint64(lx.ByteMap[(as>>7^s1)&mk])<<11
+	as = as<<9 ^ as>>11 ^ uint64(lx.ByteMap[(v2^as^s1)&mk])<<3
+
+	s1 = s1<<7 ^ s1>>27 ^ as ^ uint64(lx.ByteMap[(as>>3)&mk])<<13
+	s1 = s1<<3 ^ s1>>13 ^ uint64(lx.ByteMap[(s1^v2)&mk])<<11
+	s1 = s1<<8 ^ s1>>11 ^ uint64(lx.ByteMap[(as^s1>>11)&mk])<<9
+	s1 = s1<<6 ^ s1>>9 ^ uint64(lx.ByteMap[(v2^as^s1)&mk])<<3
+
+	as = as<<23 ^ as>>3 ^ s1 ^ uint64(lx.ByteMap[(as^v2^s1>>3)&mk])<<7
+	as = as<<17 ^ as>>7 ^ uint64(lx.ByteMap[(as^s1>>3)&mk])<<5
+	as = as<<13 ^ as>>5 ^ uint64(lx.ByteMap[(as>>5^s1)&mk])<<1
+	as = as<<11 ^ as>>1 ^ uint64(lx.ByteMap[(v2^as^s1)&mk])<<7
+
+	s1 = s1<<5 ^ s1>>3 ^ as ^ uint64(lx.ByteMap[(as>>7^s1>>3)&mk])<<6
+	s1 = s1<<8 ^ s1>>6 ^ uint64(lx.ByteMap[(s1^v2)&mk])<<11
+	s1 = s1<<11 ^ s1>>11 ^ uint64(lx.ByteMap[(as^s1>>11)&mk])<<5
+	s1 = s1<<7 ^ s1>>5 ^ uint64(lx.ByteMap[(v2^as>>7^as^s1)&mk])<<17
+
+	s2 = s2<<3 ^ s2>>17 ^ s1 ^ uint64(lx.ByteMap[(as^s2>>5^v2)&mk])<<13
+	s2 = s2<<6 ^ s2>>13 ^ uint64(lx.ByteMap[(s2)&mk])<<11
+	s2 = s2<<11 ^ s2>>11 ^ uint64(lx.ByteMap[(as^s1^s2>>11)&mk])<<23
+	s2 = s2<<4 ^ s2>>23 ^ uint64(lx.ByteMap[(v2^as>>8^as^s2>>10)&mk])<<1
+
+	s1 = s2<<3 ^ s2>>1 ^ *h ^ v2
+	as = as<<9 ^ as>>7 ^ s1>>1 ^ uint64(lx.ByteMap[(s2>>1^*h)&mk])<<5
+
+	s1, s2, s3 = s3, s1, s2
+
+	return as, s1, s2, s3
+}
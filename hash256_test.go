@@ -0,0 +1,55 @@
+package lxr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+func TestLXRHash_Hash256(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for _, l := range fastParams() {
+		if l.HashSize != 32 {
+			continue
+		}
+		for _, length := range []int{0, 1, 31, 32, 33, 64, 65, 200} {
+			src := make([]byte, length)
+			r.Read(src)
+
+			// Hash dispatches to Hash256, so compare with the paths that reduce generically
+			got := l.Hash256(src)
+			if want := l.FlatHash(src); !bytes.Equal(got[:], want) {
+				t.Errorf("%s: mismatch for %x. got = %x, want = %x", paramName(l), src, got, want)
+			}
+			if want := l.HashParallel(nil, [][]byte{src}); !bytes.Equal(got[:], want[0]) {
+				t.Errorf("%s: HashParallel does not match for %x. got = %x, want = %x", paramName(l), src, got, want[0])
+			}
+		}
+	}
+
+	for k, v := range knownHashes {
+		got := lx.Hash256([]byte(k))
+		if want, _ := hex.DecodeString(v); !bytes.Equal(got[:], want) {
+			t.Errorf("mismatch for %s. got = %x, want = %s", k, got, v)
+		}
+	}
+}
+
+func BenchmarkHash256(b *testing.B) {
+	small := new(LXRHash)
+	small.Init(Seed, 16, HashSize, Passes)
+
+	for _, l := range []*LXRHash{small, &lx} {
+		b.Run(paramName(*l)+"/flat hash", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l.FlatHash(append(oprhash, byte(i), byte(i>>8), byte(i>>16)))
+			}
+		})
+		b.Run(paramName(*l)+"/Hash256", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				l.Hash256(append(oprhash, byte(i), byte(i>>8), byte(i>>16)))
+			}
+		})
+	}
+}
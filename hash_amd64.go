@@ -0,0 +1,27 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+package lxr
+
+// The passes of FastHash and FastHashParallel, and the prefetching of HashLanes,
+// implemented in hash_amd64.s
+
+//go:noescape
+func fastPass(bm []byte, mk uint64, st *asmState, hs []uint64, src []byte)
+
+//go:noescape
+func stepPass(bm []byte, mk uint64, st *asmState, hs []uint64, src []byte)
+
+//go:noescape
+func reducePass(bm []byte, mk uint64, st *asmState, hs []uint64, out []byte)
+
+//go:noescape
+func stepLanes(bm []byte, mk uint64, lanes []lane, hs []uint64, size uint64, idx uint64)
+
+// prefetchIndexes prefetches the bytes of the ByteMap at each of the indexes
+//
+//go:noescape
+func prefetchIndexes(bm []byte, ix []uint64)
@@ -0,0 +1,1255 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+//go:build amd64 && !purego
+// +build amd64,!purego
+
+#include "textflag.h"
+
+// The shifts and lookups below must match stepf and fastStepf in lxrhash.go exactly.
+// Indexes into the ByteMap are masked by MapSize-1 and are not bounds checked, so callers
+// must ensure the ByteMap holds MapSize bytes, that hs is not empty, and that hs and the output
+// are as long as expected.
+
+// FASTSTEP is fastStepf for the single lane registers used by STEP
+#define FASTSTEP \
+	MOVQ	R8, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$7, BX; \
+	SHRQ	$5, R8; \
+	XORQ	BX, R8; \
+	MOVQ	R12, BX; \
+	SHLQ	$20, BX; \
+	XORQ	BX, R8; \
+	MOVQ	R12, BX; \
+	SHLQ	$16, BX; \
+	XORQ	BX, R8; \
+	XORQ	R12, R8; \
+	MOVQ	AX, BX; \
+	SHLQ	$20, BX; \
+	XORQ	BX, R8; \
+	MOVQ	AX, BX; \
+	SHLQ	$12, BX; \
+	XORQ	BX, R8; \
+	SHLQ	$4, AX; \
+	XORQ	AX, R8; \
+	MOVQ	R9, BX; \
+	SHLQ	$9, BX; \
+	SHRQ	$3, R9; \
+	XORQ	BX, R9; \
+	XORQ	(R13), R9; \
+	MOVQ	R9, AX; \
+	XORQ	R8, AX; \
+	MOVQ	AX, (R13); \
+	MOVQ	R10, AX; \
+	MOVQ	R9, R10; \
+	MOVQ	R11, R9; \
+	MOVQ	AX, R11
+
+// STEP is one stepf of the hash, with the state held in registers:
+//   SI = &ByteMap[0], DI = MapSize-1
+//   R8 = as, R9 = s1, R10 = s2, R11 = s3, R12 = v2, R13 = &hs[idx]
+// AX and BX are clobbered.
+#define STEP \
+	MOVQ	R8, AX; \
+	SHRQ	$5, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$3, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$9, BX; \
+	SHRQ	$1, R9; \
+	XORQ	BX, R9; \
+	XORQ	R8, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R9, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$7, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$5, BX; \
+	SHRQ	$3, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R8, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$7, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$5, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$7, BX; \
+	SHRQ	$7, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R12, AX; \
+	MOVQ	R8, BX; \
+	SHRQ	$11, BX; \
+	XORQ	BX, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$27, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$11, BX; \
+	SHRQ	$5, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	(R13), AX; \
+	MOVQ	AX, BX; \
+	SHLQ	$7, AX; \
+	SHRQ	$13, BX; \
+	XORQ	BX, AX; \
+	XORQ	R9, AX; \
+	XORQ	R8, AX; \
+	MOVQ	AX, (R13); \
+	MOVQ	R8, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$27, BX; \
+	XORQ	BX, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$3, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$17, BX; \
+	SHRQ	$5, R8; \
+	XORQ	BX, R8; \
+	XORQ	R9, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R8, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$7, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$13, BX; \
+	SHRQ	$3, R8; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R8, AX; \
+	SHRQ	$7, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$11, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$15, BX; \
+	SHRQ	$7, R8; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R12, AX; \
+	XORQ	R8, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$3, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$9, BX; \
+	SHRQ	$11, R8; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R8, AX; \
+	SHRQ	$3, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$13, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$7, BX; \
+	SHRQ	$27, R9; \
+	XORQ	BX, R9; \
+	XORQ	R8, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R9, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$11, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$3, BX; \
+	SHRQ	$13, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R8, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$11, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$9, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$8, BX; \
+	SHRQ	$11, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R12, AX; \
+	XORQ	R8, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$3, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$6, BX; \
+	SHRQ	$9, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R8, AX; \
+	XORQ	R12, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$3, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$7, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$23, BX; \
+	SHRQ	$3, R8; \
+	XORQ	BX, R8; \
+	XORQ	R9, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R8, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$3, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$5, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$17, BX; \
+	SHRQ	$7, R8; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R8, AX; \
+	SHRQ	$5, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$1, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$13, BX; \
+	SHRQ	$5, R8; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R12, AX; \
+	XORQ	R8, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$7, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$11, BX; \
+	SHRQ	$1, R8; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R8, AX; \
+	SHRQ	$7, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$3, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$6, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$5, BX; \
+	SHRQ	$3, R9; \
+	XORQ	BX, R9; \
+	XORQ	R8, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R9, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$11, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$8, BX; \
+	SHRQ	$6, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R8, AX; \
+	MOVQ	R9, BX; \
+	SHRQ	$11, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$5, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$11, BX; \
+	SHRQ	$11, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R12, AX; \
+	MOVQ	R8, BX; \
+	SHRQ	$7, BX; \
+	XORQ	BX, AX; \
+	XORQ	R8, AX; \
+	XORQ	R9, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$17, AX; \
+	MOVQ	R9, BX; \
+	SHLQ	$7, BX; \
+	SHRQ	$5, R9; \
+	XORQ	BX, R9; \
+	XORQ	AX, R9; \
+	MOVQ	R8, AX; \
+	MOVQ	R10, BX; \
+	SHRQ	$5, BX; \
+	XORQ	BX, AX; \
+	XORQ	R12, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$13, AX; \
+	MOVQ	R10, BX; \
+	SHLQ	$3, BX; \
+	SHRQ	$17, R10; \
+	XORQ	BX, R10; \
+	XORQ	R9, R10; \
+	XORQ	AX, R10; \
+	MOVQ	R10, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$11, AX; \
+	MOVQ	R10, BX; \
+	SHLQ	$6, BX; \
+	SHRQ	$13, R10; \
+	XORQ	BX, R10; \
+	XORQ	AX, R10; \
+	MOVQ	R8, AX; \
+	XORQ	R9, AX; \
+	MOVQ	R10, BX; \
+	SHRQ	$11, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$23, AX; \
+	MOVQ	R10, BX; \
+	SHLQ	$11, BX; \
+	SHRQ	$11, R10; \
+	XORQ	BX, R10; \
+	XORQ	AX, R10; \
+	MOVQ	R12, AX; \
+	MOVQ	R8, BX; \
+	SHRQ	$8, BX; \
+	XORQ	BX, AX; \
+	XORQ	R8, AX; \
+	MOVQ	R10, BX; \
+	SHRQ	$10, BX; \
+	XORQ	BX, AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$1, AX; \
+	MOVQ	R10, BX; \
+	SHLQ	$4, BX; \
+	SHRQ	$23, R10; \
+	XORQ	BX, R10; \
+	XORQ	AX, R10; \
+	MOVQ	R10, R9; \
+	SHLQ	$3, R9; \
+	MOVQ	R10, BX; \
+	SHRQ	$1, BX; \
+	XORQ	BX, R9; \
+	XORQ	(R13), R9; \
+	XORQ	R12, R9; \
+	MOVQ	R10, AX; \
+	SHRQ	$1, AX; \
+	XORQ	(R13), AX; \
+	ANDQ	DI, AX; \
+	MOVBQZX	(SI)(AX*1), AX; \
+	SHLQ	$5, AX; \
+	MOVQ	R8, BX; \
+	SHLQ	$9, BX; \
+	SHRQ	$7, R8; \
+	XORQ	BX, R8; \
+	MOVQ	R9, BX; \
+	SHRQ	$1, BX; \
+	XORQ	BX, R8; \
+	XORQ	AX, R8; \
+	MOVQ	R10, AX; \
+	MOVQ	R9, R10; \
+	MOVQ	R11, R9; \
+	MOVQ	AX, R11
+
+// func fastPass(bm []byte, mk uint64, st *asmState, hs []uint64, src []byte)
+TEXT ·fastPass(SB), NOSPLIT, $0-88
+	MOVQ	bm_base+0(FP), SI
+	MOVQ	mk+24(FP), DI
+	MOVQ	st+32(FP), AX
+	MOVQ	0(AX), R8
+	MOVQ	8(AX), R9
+	MOVQ	16(AX), R10
+	MOVQ	24(AX), R11
+	MOVQ	hs_base+40(FP), CX
+	MOVQ	hs_len+48(FP), DX
+	LEAQ	(CX)(DX*8), DX
+	MOVQ	CX, R13
+	MOVQ	src_base+64(FP), R14
+	MOVQ	src_len+72(FP), R15
+	ADDQ	R14, R15
+
+fastloop:
+	CMPQ	R14, R15
+	JAE	fastdone
+	CMPQ	R13, DX
+	JB	fastnowrap
+	MOVQ	CX, R13
+
+fastnowrap:
+	MOVBQZX	(R14), R12
+	FASTSTEP
+	ADDQ	$8, R13
+	INCQ	R14
+	JMP	fastloop
+
+fastdone:
+	MOVQ	st+32(FP), AX
+	MOVQ	R8, 0(AX)
+	MOVQ	R9, 8(AX)
+	MOVQ	R10, 16(AX)
+	MOVQ	R11, 24(AX)
+	RET
+
+// func stepPass(bm []byte, mk uint64, st *asmState, hs []uint64, src []byte)
+TEXT ·stepPass(SB), NOSPLIT, $0-88
+	MOVQ	bm_base+0(FP), SI
+	MOVQ	mk+24(FP), DI
+	MOVQ	st+32(FP), AX
+	MOVQ	0(AX), R8
+	MOVQ	8(AX), R9
+	MOVQ	16(AX), R10
+	MOVQ	24(AX), R11
+	MOVQ	hs_base+40(FP), CX
+	MOVQ	hs_len+48(FP), DX
+	LEAQ	(CX)(DX*8), DX
+	MOVQ	CX, R13
+	MOVQ	src_base+64(FP), R14
+	MOVQ	src_len+72(FP), R15
+	ADDQ	R14, R15
+
+steploop:
+	CMPQ	R14, R15
+	JAE	stepdone
+	CMPQ	R13, DX
+	JB	stepnowrap
+	MOVQ	CX, R13
+
+stepnowrap:
+	MOVBQZX	(R14), R12
+	STEP
+	ADDQ	$8, R13
+	INCQ	R14
+	JMP	steploop
+
+stepdone:
+	MOVQ	st+32(FP), AX
+	MOVQ	R8, 0(AX)
+	MOVQ	R9, 8(AX)
+	MOVQ	R10, 16(AX)
+	MOVQ	R11, 24(AX)
+	RET
+
+// func reducePass(bm []byte, mk uint64, st *asmState, hs []uint64, out []byte)
+TEXT ·reducePass(SB), NOSPLIT, $0-88
+	MOVQ	bm_base+0(FP), SI
+	MOVQ	mk+24(FP), DI
+	MOVQ	st+32(FP), AX
+	MOVQ	0(AX), R8
+	MOVQ	8(AX), R9
+	MOVQ	16(AX), R10
+	MOVQ	24(AX), R11
+	MOVQ	hs_base+40(FP), CX
+	MOVQ	hs_len+48(FP), R15
+	MOVQ	out_base+64(FP), R14
+
+reduceloop:
+	SUBQ	$1, R15
+	JCS	reducedone
+	LEAQ	(CX)(R15*8), R13
+	MOVQ	(R13), R12
+	STEP
+	MOVQ	R8, AX
+	ANDQ	DI, AX
+	MOVBLZX	(SI)(AX*1), AX
+	MOVQ	(R13), BX
+	ANDQ	DI, BX
+	MOVBLZX	(SI)(BX*1), BX
+	XORL	BX, AX
+	MOVB	AX, (R14)(R15*1)
+	JMP	reduceloop
+
+reducedone:
+	MOVQ	st+32(FP), AX
+	MOVQ	R8, 0(AX)
+	MOVQ	R9, 8(AX)
+	MOVQ	R10, 16(AX)
+	MOVQ	R11, 24(AX)
+	RET
+
+// func stepLanes(bm []byte, mk uint64, lanes []lane, hs []uint64, size uint64, idx uint64)
+//
+// stepLanes is one stepf of every lane, where each line of the step is applied to all the
+// lanes before moving on to the next, so the lookups of the lanes are in flight together.
+// The hs of lane j is hs[j*size:(j+1)*size].  There must be at least one lane.
+//
+// Registers:
+//   SI = &ByteMap[0], DI = MapSize-1, DX = size*8
+//   R15 = &lanes[0], R14 = &hs[idx] of lane 0
+//   R8 = &lanes[j], R13 = &hs[idx] of lane j, CX = lanes remaining
+//   R9 = as, R10 = s1, R11 = s2, R12 = v2 of lane j
+TEXT ·stepLanes(SB), NOSPLIT, $0-96
+	MOVQ	bm_base+0(FP), SI
+	MOVQ	mk+24(FP), DI
+	MOVQ	lanes_base+32(FP), R15
+	MOVQ	hs_base+56(FP), R14
+	MOVQ	idx+88(FP), AX
+	LEAQ	(R14)(AX*8), R14
+	MOVQ	size+80(FP), DX
+	SHLQ	$3, DX
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes0:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	SHRQ	$5, AX
+	XORQ	R12, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$3, AX
+	MOVQ	R10, BX
+	SHLQ	$9, BX
+	SHRQ	$1, R10
+	XORQ	BX, R10
+	XORQ	R9, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes0
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes1:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R10, AX
+	XORQ	R12, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$7, AX
+	MOVQ	R10, BX
+	SHLQ	$5, BX
+	SHRQ	$3, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes1
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes2:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	MOVQ	R10, BX
+	SHRQ	$7, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$5, AX
+	MOVQ	R10, BX
+	SHLQ	$7, BX
+	SHRQ	$7, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes2
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes3:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R12, AX
+	MOVQ	R9, BX
+	SHRQ	$11, BX
+	XORQ	BX, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$27, AX
+	MOVQ	R10, BX
+	SHLQ	$11, BX
+	SHRQ	$5, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes3
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes4:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	(R13), AX
+	MOVQ	AX, BX
+	SHLQ	$7, AX
+	SHRQ	$13, BX
+	XORQ	BX, AX
+	XORQ	R10, AX
+	XORQ	R9, AX
+	MOVQ	AX, (R13)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes4
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes5:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	MOVQ	R10, BX
+	SHRQ	$27, BX
+	XORQ	BX, AX
+	XORQ	R12, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$3, AX
+	MOVQ	R9, BX
+	SHLQ	$17, BX
+	SHRQ	$5, R9
+	XORQ	BX, R9
+	XORQ	R10, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes5
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes6:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$7, AX
+	MOVQ	R9, BX
+	SHLQ	$13, BX
+	SHRQ	$3, R9
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes6
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes7:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	SHRQ	$7, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$11, AX
+	MOVQ	R9, BX
+	SHLQ	$15, BX
+	SHRQ	$7, R9
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes7
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes8:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R12, AX
+	XORQ	R9, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$3, AX
+	MOVQ	R9, BX
+	SHLQ	$9, BX
+	SHRQ	$11, R9
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes8
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes9:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	SHRQ	$3, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$13, AX
+	MOVQ	R10, BX
+	SHLQ	$7, BX
+	SHRQ	$27, R10
+	XORQ	BX, R10
+	XORQ	R9, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes9
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes10:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R10, AX
+	XORQ	R12, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$11, AX
+	MOVQ	R10, BX
+	SHLQ	$3, BX
+	SHRQ	$13, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes10
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes11:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	MOVQ	R10, BX
+	SHRQ	$11, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$9, AX
+	MOVQ	R10, BX
+	SHLQ	$8, BX
+	SHRQ	$11, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes11
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes12:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R12, AX
+	XORQ	R9, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$3, AX
+	MOVQ	R10, BX
+	SHLQ	$6, BX
+	SHRQ	$9, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes12
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes13:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	XORQ	R12, AX
+	MOVQ	R10, BX
+	SHRQ	$3, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$7, AX
+	MOVQ	R9, BX
+	SHLQ	$23, BX
+	SHRQ	$3, R9
+	XORQ	BX, R9
+	XORQ	R10, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes13
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes14:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	MOVQ	R10, BX
+	SHRQ	$3, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$5, AX
+	MOVQ	R9, BX
+	SHLQ	$17, BX
+	SHRQ	$7, R9
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes14
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes15:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	SHRQ	$5, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$1, AX
+	MOVQ	R9, BX
+	SHLQ	$13, BX
+	SHRQ	$5, R9
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes15
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes16:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R12, AX
+	XORQ	R9, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$7, AX
+	MOVQ	R9, BX
+	SHLQ	$11, BX
+	SHRQ	$1, R9
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes16
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes17:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	SHRQ	$7, AX
+	MOVQ	R10, BX
+	SHRQ	$3, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$6, AX
+	MOVQ	R10, BX
+	SHLQ	$5, BX
+	SHRQ	$3, R10
+	XORQ	BX, R10
+	XORQ	R9, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes17
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes18:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R10, AX
+	XORQ	R12, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$11, AX
+	MOVQ	R10, BX
+	SHLQ	$8, BX
+	SHRQ	$6, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes18
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes19:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	MOVQ	R10, BX
+	SHRQ	$11, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$5, AX
+	MOVQ	R10, BX
+	SHLQ	$11, BX
+	SHRQ	$11, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes19
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes20:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R12, AX
+	MOVQ	R9, BX
+	SHRQ	$7, BX
+	XORQ	BX, AX
+	XORQ	R9, AX
+	XORQ	R10, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$17, AX
+	MOVQ	R10, BX
+	SHLQ	$7, BX
+	SHRQ	$5, R10
+	XORQ	BX, R10
+	XORQ	AX, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes20
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes21:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	MOVQ	R11, BX
+	SHRQ	$5, BX
+	XORQ	BX, AX
+	XORQ	R12, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$13, AX
+	MOVQ	R11, BX
+	SHLQ	$3, BX
+	SHRQ	$17, R11
+	XORQ	BX, R11
+	XORQ	R10, R11
+	XORQ	AX, R11
+	MOVQ	R11, 16(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes21
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes22:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R11, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$11, AX
+	MOVQ	R11, BX
+	SHLQ	$6, BX
+	SHRQ	$13, R11
+	XORQ	BX, R11
+	XORQ	AX, R11
+	MOVQ	R11, 16(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes22
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes23:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R9, AX
+	XORQ	R10, AX
+	MOVQ	R11, BX
+	SHRQ	$11, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$23, AX
+	MOVQ	R11, BX
+	SHLQ	$11, BX
+	SHRQ	$11, R11
+	XORQ	BX, R11
+	XORQ	AX, R11
+	MOVQ	R11, 16(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes23
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes24:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R12, AX
+	MOVQ	R9, BX
+	SHRQ	$8, BX
+	XORQ	BX, AX
+	XORQ	R9, AX
+	MOVQ	R11, BX
+	SHRQ	$10, BX
+	XORQ	BX, AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$1, AX
+	MOVQ	R11, BX
+	SHLQ	$4, BX
+	SHRQ	$23, R11
+	XORQ	BX, R11
+	XORQ	AX, R11
+	MOVQ	R11, 16(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes24
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes25:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R11, R10
+	SHLQ	$3, R10
+	MOVQ	R11, BX
+	SHRQ	$1, BX
+	XORQ	BX, R10
+	XORQ	(R13), R10
+	XORQ	R12, R10
+	MOVQ	R10, 8(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes25
+
+	MOVQ	R15, R8
+	MOVQ	R14, R13
+	MOVQ	lanes_len+40(FP), CX
+
+lanes26:
+	MOVQ	0(R8), R9
+	MOVQ	8(R8), R10
+	MOVQ	16(R8), R11
+	MOVQ	32(R8), R12
+	MOVQ	R11, AX
+	SHRQ	$1, AX
+	XORQ	(R13), AX
+	ANDQ	DI, AX
+	MOVBQZX	(SI)(AX*1), AX
+	SHLQ	$5, AX
+	MOVQ	R9, BX
+	SHLQ	$9, BX
+	SHRQ	$7, R9
+	XORQ	BX, R9
+	MOVQ	R10, BX
+	SHRQ	$1, BX
+	XORQ	BX, R9
+	XORQ	AX, R9
+	MOVQ	R9, 0(R8)
+	MOVQ	24(R8), AX
+	MOVQ	AX, 8(R8)
+	MOVQ	R10, 16(R8)
+	MOVQ	R11, 24(R8)
+	ADDQ	$40, R8
+	ADDQ	DX, R13
+	DECQ	CX
+	JNZ	lanes26
+	RET
+
+// func prefetchIndexes(bm []byte, ix []uint64)
+TEXT ·prefetchIndexes(SB), NOSPLIT, $0-48
+	MOVQ	bm_base+0(FP), SI
+	MOVQ	ix_base+24(FP), DI
+	MOVQ	ix_len+32(FP), CX
+	TESTQ	CX, CX
+	JZ	prefetchdone
+
+prefetchloop:
+	MOVQ	(DI), AX
+	PREFETCHT0	(SI)(AX*1)
+	ADDQ	$8, DI
+	DECQ	CX
+	JNZ	prefetchloop
+
+prefetchdone:
+	RET
@@ -0,0 +1,56 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+//go:build !amd64 || purego
+// +build !amd64 purego
+
+package lxr
+
+// The passes of FastHash and FastHashParallel, and the prefetching of HashLanes, in pure
+// Go for platforms without an assembly implementation.  The lanes are simply stepped one
+// after the other.
+
+func fastPass(bm []byte, mk uint64, st *asmState, hs []uint64, src []byte) {
+	lx := LXRHash{ByteMap: bm, MapSize: mk + 1}
+	idx := uint64(0)
+	for _, v2 := range src {
+		if idx >= uint64(len(hs)) {
+			idx = 0
+		}
+		st.as, st.s1, st.s2, st.s3 = lx.fastStepf(uint64(v2), st.as, st.s1, st.s2, st.s3, idx, hs)
+		idx++
+	}
+}
+
+func stepPass(bm []byte, mk uint64, st *asmState, hs []uint64, src []byte) {
+	lx := LXRHash{ByteMap: bm, MapSize: mk + 1}
+	idx := uint64(0)
+	for _, v2 := range src {
+		if idx >= uint64(len(hs)) {
+			idx = 0
+		}
+		st.as, st.s1, st.s2, st.s3 = lx.stepf(st.as, st.s1, st.s2, st.s3, uint64(v2), hs, idx, mk)
+		idx++
+	}
+}
+
+func reducePass(bm []byte, mk uint64, st *asmState, hs []uint64, out []byte) {
+	lx := LXRHash{ByteMap: bm, MapSize: mk + 1}
+	for i := len(hs) - 1; i >= 0; i-- {
+		st.as, st.s1, st.s2, st.s3 = lx.stepf(st.as, st.s1, st.s2, st.s3, hs[i], hs, uint64(i), mk)
+		out[i] = bm[st.as&mk] ^ bm[hs[i]&mk]
+	}
+}
+
+func stepLanes(bm []byte, mk uint64, lanes []lane, hs []uint64, size uint64, idx uint64) {
+	lx := LXRHash{ByteMap: bm, MapSize: mk + 1}
+	for j := range lanes {
+		l := &lanes[j]
+		h := hs[uint64(j)*size : uint64(j+1)*size]
+		l.as, l.s1, l.s2, l.s3 = lx.stepf(l.as, l.s1, l.s2, l.s3, l.v2, h, idx, mk)
+	}
+}
+
+// prefetchIndexes does nothing without assembly; HashLanes falls back to relying on
+// the processor to overlap the lookups of the lanes
+func prefetchIndexes(bm []byte, ix []uint64) {}
@@ -0,0 +1,12 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+//go:build !arm
+// +build !arm
+
+package lxr
+
+import "syscall"
+
+// mapHugeTLB is the mmap flag for mappings backed by hugetlbfs
+const mapHugeTLB = syscall.MAP_HUGETLB
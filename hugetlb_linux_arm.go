@@ -0,0 +1,7 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+// mapHugeTLB is the mmap flag for mappings backed by hugetlbfs.  The syscall package does
+// not define MAP_HUGETLB on arm, but the kernel uses the same value as on x86.
+const mapHugeTLB = 0x40000
@@ -0,0 +1,89 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TableSource tells where the ByteMap of an LXRHash came from
+type TableSource int
+
+const (
+	SourceNone      TableSource = iota // No table has been loaded, or it was set directly
+	SourceGenerated                    // Generated by GenerateTable
+	SourceLoaded                       // Read from the disk cache or LoadTable
+	SourceMapped                       // Mapped from a shared table by InitShared
+)
+
+func (s TableSource) String() string {
+	switch s {
+	case SourceNone:
+		return "none"
+	case SourceGenerated:
+		return "generated"
+	case SourceLoaded:
+		return "loaded"
+	case SourceMapped:
+		return "mapped"
+	}
+	return fmt.Sprintf("TableSource(%d)", int(s))
+}
+
+// tableInfo records how the ByteMap was loaded.  It is shared by copies of the LXRHash, so the
+// digest only has to be computed once.
+type tableInfo struct {
+	source   TableSource
+	loadTime time.Duration
+
+	digestOnce sync.Once
+	digest     [sha256.Size]byte
+}
+
+// loaded records that the ByteMap was loaded from source, starting at start
+func (lx *LXRHash) loaded(source TableSource, start time.Time) {
+	lx.info = &tableInfo{source: source, loadTime: time.Since(start)}
+}
+
+// Params returns the Params of the LXRHash, as passed to Init
+func (lx *LXRHash) Params() Params {
+	return Params{Seed: lx.Seed, MapSizeBits: lx.MapSizeBits, HashSize: lx.HashSize * 8, Passes: lx.Passes}
+}
+
+// TableSize returns the length of the ByteMap in bytes
+func (lx *LXRHash) TableSize() uint64 {
+	return uint64(len(lx.ByteMap))
+}
+
+// Digest returns the SHA-256 of the ByteMap.  Two tables with the same digest are identical,
+// so comparing digests confirms every node uses the same table.  The digest is computed the
+// first time it is asked for after the table is loaded, which takes a few seconds for a 1 GB
+// table.
+func (lx *LXRHash) Digest() [sha256.Size]byte {
+	if lx.info == nil {
+		return sha256.Sum256(lx.ByteMap)
+	}
+	lx.info.digestOnce.Do(func() {
+		lx.info.digest = sha256.Sum256(lx.ByteMap)
+	})
+	return lx.info.digest
+}
+
+// Source returns where the ByteMap came from
+func (lx *LXRHash) Source() TableSource {
+	if lx.info == nil {
+		return SourceNone
+	}
+	return lx.info.source
+}
+
+// LoadTime returns how long it took to generate, load or map the ByteMap
+func (lx *LXRHash) LoadTime() time.Duration {
+	if lx.info == nil {
+		return 0
+	}
+	return lx.info.loadTime
+}
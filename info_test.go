@@ -0,0 +1,66 @@
+package lxr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestLXRHash_Digest(t *testing.T) {
+	one := new(LXRHash)
+	one.Init(Seed, 12, HashSize, Passes)
+	if src := one.Source(); src != SourceLoaded && src != SourceGenerated {
+		t.Errorf("unexpected source %s after Init", src)
+	}
+	if p := one.Params(); p != (Params{Seed, 12, HashSize, Passes}) {
+		t.Errorf("unexpected params %+v", p)
+	}
+	if size := one.TableSize(); size != 4096 {
+		t.Errorf("expected a table of 4096 bytes, got %d", size)
+	}
+
+	two := new(LXRHash)
+	two.Init(Seed, 12, HashSize, Passes)
+	two.GenerateTable()
+	if src := two.Source(); src != SourceGenerated {
+		t.Errorf("expected source %s after GenerateTable, got %s", SourceGenerated, src)
+	}
+	if two.LoadTime() <= 0 {
+		t.Errorf("expected a load time, got %s", two.LoadTime())
+	}
+
+	var buf bytes.Buffer
+	if err := two.ExportTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	three := new(LXRHash)
+	if err := three.LoadTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if src := three.Source(); src != SourceLoaded {
+		t.Errorf("expected source %s after LoadTable, got %s", SourceLoaded, src)
+	}
+
+	want := sha256.Sum256(one.ByteMap)
+	for _, l := range []*LXRHash{one, two, three} {
+		if got := l.Digest(); got != want {
+			t.Errorf("digest of %s table = %x, want %x", l.Source(), got, want)
+		}
+	}
+	// Copies share the cached digest
+	c := *three
+	if c.Digest() != want || c.info != three.info {
+		t.Error("copy does not share the digest")
+	}
+
+	other := new(LXRHash)
+	other.Init(Seed, 12, HashSize, Passes+1)
+	if other.Digest() == want {
+		t.Error("different tables have the same digest")
+	}
+
+	other.Close()
+	if other.Source() != SourceNone || other.LoadTime() != 0 || other.TableSize() != 0 {
+		t.Errorf("closed table still reports source %s, load time %s, size %d", other.Source(), other.LoadTime(), other.TableSize())
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// keyMarker starts the key block absorbed ahead of the input by KeyedHash
+var keyMarker = [4]byte{'L', 'X', 'R', 'K'}
+
+// block returns a block absorbed ahead of the input: the 4 byte marker, the length of data
+// in bytes as 8 byte big endian, then data
+func block(marker [4]byte, data []byte) []byte {
+	b := make([]byte, len(marker)+8, len(marker)+8+len(data))
+	copy(b, marker[:])
+	binary.BigEndian.PutUint64(b[len(marker):], uint64(len(data)))
+	return append(b, data...)
+}
+
+// KeyedHash returns the hash of src keyed by a secret key, for use as a MAC.  It is the Hash
+// of the key block followed by src, where the key block is the 4 bytes "LXRK", the length of
+// the key in bytes as 8 byte big endian, and the key.  The state src is hashed from so
+// depends on the key, and the length makes the split between the key and src unambiguous,
+// so no two keys give the same input to Hash.  Keys of any length are accepted, but should
+// be at least as long as the hash.  Compare MACs with hmac.Equal.
+//
+// Like Hash, KeyedHash is meant for PoW and has not been analysed as a cryptographic MAC.
+func (lx LXRHash) KeyedHash(key, src []byte) []byte {
+	return lx.Hash(append(block(keyMarker, key), src...))
+}
+
+// hasher implements hash.Hash over an LXRHash.  LXRHash makes two passes over its input, so
+// the input is buffered until Sum.
+type hasher struct {
+	lx     LXRHash
+	prefix int                     // Length of the block absorbed ahead of the input
+	buf    []byte                  // The block, followed by the input written so far
+	sum    func(src []byte) []byte // Hashes buf, if not Hash
+}
+
+// NewHash returns a hash.Hash computing Hash.  The input is held in memory until Sum.
+func (lx LXRHash) NewHash() hash.Hash {
+	return &hasher{lx: lx}
+}
+
+// NewKeyedHash returns a hash.Hash computing KeyedHash with the given key.  The input is held
+// in memory until Sum.
+func (lx LXRHash) NewKeyedHash(key []byte) hash.Hash {
+	b := block(keyMarker, key)
+	return &hasher{lx: lx, prefix: len(b), buf: b}
+}
+
+func (h *hasher) Write(p []byte) (int, error) {
+	h.buf = append(h.buf, p...)
+	return len(p), nil
+}
+
+func (h *hasher) Sum(b []byte) []byte {
+	if h.sum != nil {
+		return append(b, h.sum(h.buf)...)
+	}
+	return append(b, h.lx.Hash(h.buf)...)
+}
+
+func (h *hasher) Reset() {
+	h.buf = h.buf[:h.prefix]
+}
+
+func (h *hasher) Size() int {
+	return int(h.lx.HashSize)
+}
+
+// BlockSize returns 1, as LXRHash consumes its input a byte at a time
+func (h *hasher) BlockSize() int {
+	return 1
+}
@@ -0,0 +1,70 @@
+package lxr
+
+import (
+	"bytes"
+	"hash"
+	"testing"
+)
+
+func TestLXRHash_KeyedHash(t *testing.T) {
+	for _, l := range fastParams() {
+		src := []byte("message to authenticate")
+		key := []byte("0123456789abcdef0123456789abcdef")
+
+		mac := l.KeyedHash(key, src)
+		if len(mac) != int(l.HashSize) {
+			t.Fatalf("%s: got %d bytes, expected %d", paramName(l), len(mac), l.HashSize)
+		}
+		if want := l.Hash(append(append([]byte("LXRK\x00\x00\x00\x00\x00\x00\x00\x20"), key...), src...)); !bytes.Equal(mac, want) {
+			t.Errorf("%s: keyed hash does not follow the key block construction", paramName(l))
+		}
+	}
+}
+
+func TestLXRHash_KeyedHashSeparation(t *testing.T) {
+	src := []byte("message to authenticate")
+	key := []byte("0123456789abcdef0123456789abcdef")
+	for _, l := range distinctParams() {
+		mac := l.KeyedHash(key, src)
+		if bytes.Equal(mac, l.Hash(src)) {
+			t.Errorf("%s: keyed hash equals the unkeyed hash", paramName(l))
+		}
+		if bytes.Equal(l.KeyedHash(nil, src), l.Hash(src)) {
+			t.Errorf("%s: keyed hash with an empty key equals the unkeyed hash", paramName(l))
+		}
+		if bytes.Equal(mac, l.KeyedHash(key[1:], src)) {
+			t.Errorf("%s: different keys give the same hash", paramName(l))
+		}
+		// The length of the key separates it from the message
+		if bytes.Equal(l.KeyedHash([]byte("ab"), []byte("c")), l.KeyedHash([]byte("a"), []byte("bc"))) {
+			t.Errorf("%s: key and message boundary is ambiguous", paramName(l))
+		}
+	}
+}
+
+func TestLXRHash_NewHash(t *testing.T) {
+	key := []byte("key")
+	src := []byte("written in three pieces")
+	for _, l := range fastParams() {
+		for _, c := range []struct {
+			h    hash.Hash
+			want []byte
+		}{
+			{l.NewHash(), l.Hash(src)},
+			{l.NewKeyedHash(key), l.KeyedHash(key, src)},
+		} {
+			if c.h.Size() != int(l.HashSize) {
+				t.Errorf("%s: size %d, expected %d", paramName(l), c.h.Size(), l.HashSize)
+			}
+			for i := 0; i < 2; i++ {
+				c.h.Write(src[:7])
+				c.h.Write(src[7:10])
+				c.h.Write(src[10:])
+				if got := c.h.Sum([]byte("prefix")); !bytes.Equal(got, append([]byte("prefix"), c.want...)) {
+					t.Errorf("%s: got %x, want %x", paramName(l), got, c.want)
+				}
+				c.h.Reset()
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+// laneEngine holds the state of a batch of hashes as a struct of arrays, one entry per lane.
+//
+// The hash is latency bound on random reads of the ByteMap.  Rather than waiting on each
+// read in turn, every line of the step is applied to all the lanes, and as each lane is
+// updated the index of its next lookup is computed right away into ix.  Once all the lanes
+// are done, the whole of ix is prefetched before the next line begins, so by the time the
+// first lane needs its byte the reads of all the lanes are already in flight.
+type laneEngine struct {
+	bm   []byte
+	mk   uint64
+	size uint64 // HashSize, the number of entries of hs for every lane
+
+	as, s1, s2, s3 []uint64
+	v2             []uint64 // The byte being stepped by each lane
+	ix             []uint64 // The index of the next lookup of each lane
+	hs             []uint64 // The hs of lane j is hs[j*size:(j+1)*size]
+}
+
+// HashLanes takes the arbitrary input and returns the resulting hash of length HashSize.
+// The base is prefixed to all items in the batch, and all items must be the same length.
+// The lanes of the batch are kept in a struct of arrays, and the next lookup of every
+// lane is prefetched before it is needed.  The results are identical to HashParallel.
+func (lx LXRHash) HashLanes(base []byte, batch [][]byte) [][]byte {
+	n := len(batch)
+	if n == 0 {
+		return [][]byte{}
+	}
+	e := &laneEngine{
+		bm:   lx.ByteMap,
+		mk:   lx.MapSize - 1,
+		size: lx.HashSize,
+		as:   make([]uint64, n),
+		s1:   make([]uint64, n),
+		s2:   make([]uint64, n),
+		s3:   make([]uint64, n),
+		v2:   make([]uint64, n),
+		ix:   make([]uint64, n),
+		hs:   make([]uint64, uint64(n)*lx.HashSize),
+	}
+	for j := range e.as {
+		e.as[j] = lx.Seed
+	}
+
+	length := len(base) + len(batch[0])
+	// source sets the byte at position i of the source of every lane
+	source := func(i int) {
+		for j := range e.v2 {
+			if i < len(base) {
+				e.v2[j] = uint64(base[i])
+			} else {
+				e.v2[j] = uint64(batch[j][i-len(base)])
+			}
+		}
+	}
+
+	idx := uint64(0)
+	// Fast spin to prevent caching state
+	for i := 0; i < length; i++ {
+		if idx >= e.size { // Use an if to avoid modulo math
+			idx = 0
+		}
+		source(i)
+		e.faststep(idx)
+		idx++
+	}
+
+	idx = 0
+	// Actual work to compute the hash
+	for i := 0; i < length; i++ {
+		if idx >= e.size { // Use an if to avoid modulo math
+			idx = 0
+		}
+		source(i)
+		e.step(idx)
+		idx++
+	}
+
+	ret := make([][]byte, n)
+	for j := range ret {
+		ret[j] = make([]byte, e.size)
+	}
+
+	// Reduction pass
+	for i := int64(e.size - 1); i >= 0; i-- {
+		for j := range e.v2 {
+			e.v2[j] = e.hs[uint64(j)*e.size+uint64(i)]
+		}
+		e.step(uint64(i)) // Step the hash functions and then
+		for j := range e.as {
+			ix := uint64(j)*e.size + uint64(i)
+			ret[j][i] = e.bm[e.as[j]&e.mk] ^ e.bm[e.hs[ix]&e.mk] // Xor two resulting sequences
+		}
+	}
+
+	// Return the resulting hashes
+	return ret
+}
+
+// fetch prefetches the next lookup of every lane
+func (e *laneEngine) fetch() {
+	prefetchIndexes(e.bm, e.ix)
+}
+
+// faststep is fastStepf for every lane
+func (e *laneEngine) faststep(idx uint64) {
+	as, s1, s2, s3, v2, ix := e.as, e.s1, e.s2, e.s3, e.v2, e.ix
+	for j := range ix {
+		ix[j] = (as[j] ^ v2[j]) & e.mk
+	}
+	e.fetch()
+	for j := range as {
+		h := uint64(j)*e.size + idx
+		b := uint64(e.bm[ix[j]])
+		as[j] = as[j]<<7 ^ as[j]>>5 ^ v2[j]<<20 ^ v2[j]<<16 ^ v2[j] ^ b<<20 ^ b<<12 ^ b<<4
+		s1[j] = s1[j]<<9 ^ s1[j]>>3 ^ e.hs[h]
+		e.hs[h] = s1[j] ^ as[j]
+		s1[j], s2[j], s3[j] = s3[j], s1[j], s2[j]
+	}
+}
+
+// step is stepf for every lane.  Each loop applies one line of stepf to all the lanes
+// using the bytes prefetched for it, then computes the index of the following line.
+func (e *laneEngine) step(idx uint64) {
+	bm, mk := e.bm, e.mk
+	as, s1, s2, s3, v2, ix := e.as, e.s1, e.s2, e.s3, e.v2, e.ix
+
+	for j := range ix {
+		ix[j] = (as[j]>>5 ^ v2[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<9 ^ s1[j]>>1 ^ as[j] ^ uint64(bm[ix[j]])<<3
+		ix[j] = (s1[j] ^ v2[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<5 ^ s1[j]>>3 ^ uint64(bm[ix[j]])<<7
+		ix[j] = (as[j] ^ s1[j]>>7) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<7 ^ s1[j]>>7 ^ uint64(bm[ix[j]])<<5
+		ix[j] = (v2[j] ^ as[j]>>11 ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		h := uint64(j)*e.size + idx
+		s1[j] = s1[j]<<11 ^ s1[j]>>5 ^ uint64(bm[ix[j]])<<27
+		e.hs[h] = s1[j] ^ as[j] ^ e.hs[h]<<7 ^ e.hs[h]>>13
+		ix[j] = (as[j] ^ s1[j]>>27 ^ v2[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<17 ^ as[j]>>5 ^ s1[j] ^ uint64(bm[ix[j]])<<3
+		ix[j] = (as[j] ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<13 ^ as[j]>>3 ^ uint64(bm[ix[j]])<<7
+		ix[j] = (as[j]>>7 ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<15 ^ as[j]>>7 ^ uint64(bm[ix[j]])<<11
+		ix[j] = (v2[j] ^ as[j] ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<9 ^ as[j]>>11 ^ uint64(bm[ix[j]])<<3
+		ix[j] = (as[j] >> 3) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<7 ^ s1[j]>>27 ^ as[j] ^ uint64(bm[ix[j]])<<13
+		ix[j] = (s1[j] ^ v2[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<3 ^ s1[j]>>13 ^ uint64(bm[ix[j]])<<11
+		ix[j] = (as[j] ^ s1[j]>>11) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<8 ^ s1[j]>>11 ^ uint64(bm[ix[j]])<<9
+		ix[j] = (v2[j] ^ as[j] ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<6 ^ s1[j]>>9 ^ uint64(bm[ix[j]])<<3
+		ix[j] = (as[j] ^ v2[j] ^ s1[j]>>3) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<23 ^ as[j]>>3 ^ s1[j] ^ uint64(bm[ix[j]])<<7
+		ix[j] = (as[j] ^ s1[j]>>3) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<17 ^ as[j]>>7 ^ uint64(bm[ix[j]])<<5
+		ix[j] = (as[j]>>5 ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<13 ^ as[j]>>5 ^ uint64(bm[ix[j]])<<1
+		ix[j] = (v2[j] ^ as[j] ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<11 ^ as[j]>>1 ^ uint64(bm[ix[j]])<<7
+		ix[j] = (as[j]>>7 ^ s1[j]>>3) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<5 ^ s1[j]>>3 ^ as[j] ^ uint64(bm[ix[j]])<<6
+		ix[j] = (s1[j] ^ v2[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<8 ^ s1[j]>>6 ^ uint64(bm[ix[j]])<<11
+		ix[j] = (as[j] ^ s1[j]>>11) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<11 ^ s1[j]>>11 ^ uint64(bm[ix[j]])<<5
+		ix[j] = (v2[j] ^ as[j]>>7 ^ as[j] ^ s1[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s1[j] = s1[j]<<7 ^ s1[j]>>5 ^ uint64(bm[ix[j]])<<17
+		ix[j] = (as[j] ^ s2[j]>>5 ^ v2[j]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s2[j] = s2[j]<<3 ^ s2[j]>>17 ^ s1[j] ^ uint64(bm[ix[j]])<<13
+		ix[j] = s2[j] & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s2[j] = s2[j]<<6 ^ s2[j]>>13 ^ uint64(bm[ix[j]])<<11
+		ix[j] = (as[j] ^ s1[j] ^ s2[j]>>11) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		s2[j] = s2[j]<<11 ^ s2[j]>>11 ^ uint64(bm[ix[j]])<<23
+		ix[j] = (v2[j] ^ as[j]>>8 ^ as[j] ^ s2[j]>>10) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		h := uint64(j)*e.size + idx
+		s2[j] = s2[j]<<4 ^ s2[j]>>23 ^ uint64(bm[ix[j]])<<1
+		s1[j] = s2[j]<<3 ^ s2[j]>>1 ^ e.hs[h] ^ v2[j]
+		ix[j] = (s2[j]>>1 ^ e.hs[h]) & mk
+	}
+	e.fetch()
+	for j := range ix {
+		as[j] = as[j]<<9 ^ as[j]>>7 ^ s1[j]>>1 ^ uint64(bm[ix[j]])<<5
+		s1[j], s2[j], s3[j] = s3[j], s1[j], s2[j]
+	}
+}
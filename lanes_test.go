@@ -0,0 +1,63 @@
+package lxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestLXRHash_HashLanes(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, l := range fastParams() {
+		base := make([]byte, r.Intn(40))
+		r.Read(base)
+
+		for _, size := range []int{1, 3, 16} {
+			batch := make([][]byte, size)
+			for i := range batch {
+				batch[i] = make([]byte, 4)
+				r.Read(batch[i])
+			}
+
+			got := l.HashLanes(base, batch)
+			want := l.HashParallel(base, batch)
+			for i := range batch {
+				if !bytes.Equal(got[i], want[i]) {
+					t.Errorf("%s: mismatch for item %d. got = %x, want = %x", paramName(l), i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkHashLanes compares the lane engines on the 30 bit table, mining batches of
+// nonces on the oprhash
+func BenchmarkHashLanes(b *testing.B) {
+	engines := []struct {
+		name string
+		f    BatchHasher
+	}{
+		{"HashParallel", lx.HashParallel},
+		{"FastHashParallel", lx.FastHashParallel},
+		{"HashLanes", lx.HashLanes},
+	}
+
+	for _, size := range []int{16, 64, 256} {
+		for _, engine := range engines {
+			b.Run(fmt.Sprintf("%s/%d", engine.name, size), func(b *testing.B) {
+				batch := make([][]byte, size)
+				for i := range batch {
+					batch[i] = make([]byte, 4)
+				}
+				for n := 0; n < b.N; n += size {
+					for i := range batch {
+						binary.BigEndian.PutUint32(batch[i], uint32(n+i))
+					}
+					engine.f(oprhash, batch)
+				}
+			})
+		}
+	}
+}
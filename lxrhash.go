@@ -13,6 +13,12 @@ type LXRHash struct {
 	Seed        uint64 // An arbitrary number used to create the tables.
 	HashSize    uint64 // Number of bytes in the hash
 	verbose     bool
+	best        int32        // Implementation selected by Calibrate
+	options     LoadOptions  // How to allocate the ByteMap
+	stats       LoadStats    // Which of the options took effect
+	memory      *tableMemory // Memory of the ByteMap released by a finalizer, if any, shared by copies
+	shared      *sharedTable // Shared table the ByteMap is attached to, if any
+	info        *tableInfo   // Where the ByteMap came from, and its digest
 }
 
 // AbortSettings indicated the proper settings to abort if a hash is found
@@ -298,17 +304,45 @@ func (lx LXRHash) FlatHash(src []byte) []byte {
 
 // Hash takes the arbitrary input and returns the resulting hash of length HashSize
 func (lx LXRHash) Hash(src []byte) []byte {
+	// The default 256 bit hash has a specialised implementation
+	if lx.HashSize == 32 {
+		bytes := lx.Hash256(src)
+		return bytes[:]
+	}
+
+	bm := lx.ByteMap
+	return hashLookup(lx.Seed, lx.MapSize, lx.HashSize, [][]byte{src}, func(_ Phase, index uint64) byte { return bm[index] })
+}
+
+// hashLookup is the body of Hash, with every lookup of the ByteMap made through lookup, which
+// is given the Phase of the hash and the index already masked to the ByteMap.  DiskHash reads
+// the ByteMap from disk through it and TraceHash records the lookups, so that neither has a
+// step function of its own to drift from Hash.
+//
+// The inputs are absorbed in turn, each with a fast spin and steps of its own, and the state
+// is then reduced to the hash.  Hash absorbs just its input.
+func hashLookup(seed, mapSize, hashSize uint64, inputs [][]byte, lookup func(phase Phase, index uint64) byte) []byte {
+	bytes := make([]byte, hashSize)
+	absorbLookup(seed, mapSize, hashSize, inputs, lookup)(bytes)
+	return bytes
+}
+
+// absorbLookup absorbs the inputs as hashLookup does, and returns the reduction pass.  Each
+// call of reduce writes hashSize bytes to out, carrying on from the state the call before
+// left, so the first gives the hash and the XOF reads further blocks from the next.
+func absorbLookup(seed, mapSize, hashSize uint64, inputs [][]byte, lookup func(phase Phase, index uint64) byte) (reduce func(out []byte)) {
 	// Keep the byte intermediate results as int64 values until reduced.
-	hs := make([]uint64, lx.HashSize)
+	hs := make([]uint64, hashSize)
 	// as accumulates the state as we walk through applying the source data through the lookup map
 	// and combine it with the state we are building up.
-	var as = lx.Seed
+	var as = seed
 	// We keep a series of states, and roll them along through each byte of source processed.
 	var s1, s2, s3 uint64
 	// Since MapSize is specified in bits, the index mask is the size-1
-	mk := lx.MapSize - 1
+	mk := mapSize - 1
 
-	B := func(v uint64) uint64 { return uint64(lx.ByteMap[v&mk]) }
+	var phase Phase
+	B := func(v uint64) uint64 { return uint64(lookup(phase, v&mk)) }
 	b := func(v uint64) byte { return byte(B(v)) }
 
 	faststep := func(v2 uint64, idx uint64) {
@@ -363,24 +397,28 @@ func (lx LXRHash) Hash(src []byte) []byte {
 		s1, s2, s3 = s3, s1, s2
 	}
 
-	idx := uint64(0)
-	// Fast spin to prevent caching state
-	for _, v2 := range src {
-		if idx >= lx.HashSize { // Use an if to avoid modulo math
-			idx = 0
+	for _, src := range inputs {
+		phase = PhaseFast
+		idx := uint64(0)
+		// Fast spin to prevent caching state
+		for _, v2 := range src {
+			if idx >= hashSize { // Use an if to avoid modulo math
+				idx = 0
+			}
+			faststep(uint64(v2), idx)
+			idx++
 		}
-		faststep(uint64(v2), idx)
-		idx++
-	}
 
-	idx = 0
-	// Actual work to compute the hash
-	for _, v2 := range src {
-		if idx >= lx.HashSize { // Use an if to avoid modulo math
-			idx = 0
+		phase = PhaseStep
+		idx = 0
+		// Actual work to compute the hash
+		for _, v2 := range src {
+			if idx >= hashSize { // Use an if to avoid modulo math
+				idx = 0
+			}
+			step(uint64(v2), idx)
+			idx++
 		}
-		step(uint64(v2), idx)
-		idx++
 	}
 
 	// Reduction pass
@@ -389,13 +427,12 @@ func (lx LXRHash) Hash(src []byte) []byte {
 	// At this point, we have HBits of state in hs.  We need to reduce them down to a byte,
 	// And we do so by doing a bit more bitwise math, and mapping the values through our byte map.
 
-	bytes := make([]byte, lx.HashSize)
-	// Roll over all the hs (one int64 value for every byte in the resulting hash) and reduce them to byte values
-	for i := len(hs) - 1; i >= 0; i-- {
-		step(hs[i], uint64(i))      // Step the hash functions and then
-		bytes[i] = b(as) ^ b(hs[i]) // Xor two resulting sequences
+	return func(out []byte) {
+		phase = PhaseReduce
+		// Roll over all the hs (one int64 value for every byte in the resulting hash) and reduce them to byte values
+		for i := len(hs) - 1; i >= 0; i-- {
+			step(hs[i], uint64(i))    // Step the hash functions and then
+			out[i] = b(as) ^ b(hs[i]) // Xor two resulting sequences
+		}
 	}
-
-	// Return the resulting hash
-	return bytes
 }
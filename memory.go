@@ -0,0 +1,48 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+// LoadOptions control how the memory of the ByteMap is allocated when the table is read or
+// generated.  A 1 GB table accessed at random causes constant TLB misses, and is eligible
+// for swap like any other memory.  The options are only supported on Linux, and are
+// ignored elsewhere.  Use LoadStats to see which of them took effect.
+type LoadOptions struct {
+	HugePages bool // Back the ByteMap with huge pages, from hugetlbfs if available or else transparent huge pages.  See Close.
+	Lock      bool // Lock the ByteMap into memory with mlock so it cannot be swapped out
+	Prefault  bool // Touch every page of the ByteMap when it is allocated so no page faults occur while hashing
+}
+
+// tableMemory is the memory of a ByteMap the garbage collector cannot release by itself,
+// mapped for the HugePages option or locked with the Lock option.  Copies of an LXRHash share
+// it, and a finalizer releases it once none of them refers to it any more.
+type tableMemory struct {
+	mem    []byte // The memory of the ByteMap
+	mapped bool   // The memory was mapped, and is unmapped rather than only unlocked
+}
+
+// LoadStats reports which of the LoadOptions took effect for the current ByteMap
+type LoadStats struct {
+	Options              LoadOptions // The options requested
+	HugeTLB              bool        // The ByteMap was mapped from hugetlbfs (MAP_HUGETLB)
+	TransparentHugePages bool        // The ByteMap was advised to use transparent huge pages (MADV_HUGEPAGE)
+	Locked               bool        // The ByteMap is locked into memory
+	Prefaulted           bool        // Every page of the ByteMap was touched when it was allocated
+	Shared               bool        // The ByteMap is attached to a shared table with InitShared
+}
+
+// SetLoadOptions sets the options used to allocate the ByteMap.  It must be called before Init.
+func (lx *LXRHash) SetLoadOptions(opts LoadOptions) {
+	lx.options = opts
+}
+
+// LoadStats returns which of the LoadOptions took effect when the ByteMap was allocated
+func (lx *LXRHash) LoadStats() LoadStats {
+	return lx.stats
+}
+
+// prefault touches every page of the table
+func prefault(table []byte, pageSize int) {
+	for i := 0; i < len(table); i += pageSize {
+		table[i] = 0
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// hugePageSize is the size of the huge pages hugetlbfs mappings are rounded up to
+const hugePageSize = 2 << 20
+
+// allocTable allocates the memory for a ByteMap of MapSize bytes according to the
+// LoadOptions, and records which of them took effect
+func (lx *LXRHash) allocTable() []byte {
+	lx.Close()
+	lx.stats = LoadStats{Options: lx.options}
+
+	size := int(lx.MapSize)
+	var table, mapped []byte
+	if lx.options.HugePages {
+		length := (size + hugePageSize - 1) / hugePageSize * hugePageSize
+		m, err := syscall.Mmap(-1, 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS|mapHugeTLB)
+		if err == nil {
+			mapped = m
+			lx.stats.HugeTLB = true
+		} else {
+			lx.Log(fmt.Sprintf("Huge pages from hugetlbfs not available: %v", err))
+			m, err = syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS)
+			if err != nil {
+				lx.Log(fmt.Sprintf("Could not map the ByteMap: %v", err))
+			} else {
+				mapped = m
+				if err := syscall.Madvise(m, syscall.MADV_HUGEPAGE); err != nil {
+					lx.Log(fmt.Sprintf("Transparent huge pages not available: %v", err))
+				} else {
+					lx.stats.TransparentHugePages = true
+				}
+			}
+		}
+		if mapped != nil {
+			table = mapped[:size]
+		}
+	}
+	if table == nil {
+		table = make([]byte, size)
+	}
+
+	if lx.options.Prefault {
+		prefault(table, os.Getpagesize())
+		lx.stats.Prefaulted = true
+	}
+	if lx.options.Lock {
+		if err := syscall.Mlock(table); err != nil {
+			lx.Log(fmt.Sprintf("Could not lock the ByteMap into memory: %v", err))
+		} else {
+			lx.stats.Locked = true
+		}
+	}
+	if mapped != nil {
+		lx.memory = newTableMemory(mapped, true)
+	} else if lx.stats.Locked {
+		lx.memory = newTableMemory(table, false)
+	}
+	return table
+}
+
+// newTableMemory returns the memory shared by the copies of an LXRHash, released once the
+// last of them is gone
+func newTableMemory(mem []byte, mapped bool) *tableMemory {
+	m := &tableMemory{mem: mem, mapped: mapped}
+	runtime.SetFinalizer(m, (*tableMemory).release)
+	return m
+}
+
+// Close releases the ByteMap.  Memory mapped for huge pages or locked into memory is not
+// managed by the garbage collector, and is released once neither the LXRHash nor any copy of
+// it refers to it, when the last of them is closed or collected.  A table attached with
+// InitShared is detached, and removed if no other process is attached to it.  The LXRHash
+// cannot be used for hashing once closed, but copies of it made before are not affected.
+func (lx *LXRHash) Close() error {
+	var err error
+	lx.memory = nil
+	if lx.shared != nil {
+		err = lx.shared.detach()
+		lx.shared = nil
+	}
+	lx.ByteMap = nil
+	lx.info = nil
+	lx.stats = LoadStats{Options: lx.options}
+	return err
+}
+
+// release unmaps or unlocks the memory, once nothing refers to it.  Unmapping also unlocks it.
+func (m *tableMemory) release() {
+	if m.mapped {
+		syscall.Munmap(m.mem)
+	} else {
+		syscall.Munlock(m.mem)
+	}
+	m.mem = nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+//go:build !linux
+// +build !linux
+
+package lxr
+
+import "os"
+
+// allocTable allocates the memory for a ByteMap of MapSize bytes.  Only Prefault is
+// supported outside of Linux.
+func (lx *LXRHash) allocTable() []byte {
+	lx.Close()
+	lx.stats = LoadStats{Options: lx.options}
+
+	table := make([]byte, int(lx.MapSize))
+	if lx.options.Prefault {
+		prefault(table, os.Getpagesize())
+		lx.stats.Prefaulted = true
+	}
+	return table
+}
+
+// Close releases the ByteMap.  The LXRHash cannot be used for hashing once closed.
+func (lx *LXRHash) Close() error {
+	lx.ByteMap = nil
+	lx.info = nil
+	lx.stats = LoadStats{Options: lx.options}
+	return nil
+}
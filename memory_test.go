@@ -0,0 +1,77 @@
+package lxr
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+func TestLXRHash_SetLoadOptions(t *testing.T) {
+	opts := LoadOptions{HugePages: true, Lock: true, Prefault: true}
+
+	want := new(LXRHash)
+	want.Init(Seed, 16, HashSize, Passes)
+
+	for _, generate := range []bool{false, true} {
+		got := new(LXRHash)
+		got.SetLoadOptions(opts)
+		got.Init(Seed, 16, HashSize, Passes)
+		if generate {
+			got.GenerateTable()
+		}
+
+		stats := got.LoadStats()
+		if stats.Options != opts {
+			t.Errorf("stats report options %+v, want %+v", stats.Options, opts)
+		}
+		if !stats.Prefaulted {
+			t.Error("table was not prefaulted")
+		}
+		if runtime.GOOS != "linux" && (stats.HugeTLB || stats.TransparentHugePages || stats.Locked) {
+			t.Errorf("options took effect outside of linux: %+v", stats)
+		}
+		t.Logf("generate=%v: %+v", generate, stats)
+
+		if !bytes.Equal(got.ByteMap, want.ByteMap) {
+			t.Fatalf("generate=%v: ByteMap differs from one loaded without options", generate)
+		}
+		for _, src := range [][]byte{nil, []byte("Prefaulted, locked and huge"), bytes.Repeat([]byte{0xa5}, 300)} {
+			if !bytes.Equal(got.Hash(src), want.Hash(src)) {
+				t.Errorf("generate=%v: hash of %x differs from one loaded without options", generate, src)
+			}
+		}
+
+		if err := got.Close(); err != nil {
+			t.Errorf("close: %v", err)
+		}
+		if got.ByteMap != nil || got.LoadStats() != (LoadStats{Options: opts}) {
+			t.Errorf("close did not release the table: %+v", got.LoadStats())
+		}
+	}
+}
+
+func TestLXRHash_CloseCopy(t *testing.T) {
+	want := new(LXRHash)
+	want.Init(Seed, 16, HashSize, Passes)
+
+	l := new(LXRHash)
+	l.SetLoadOptions(LoadOptions{HugePages: true, Lock: true})
+	l.Init(Seed, 16, HashSize, Passes)
+	copies := []LXRHash{*l, *l}
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// The copies keep the memory until the last of them is closed
+	for i := range copies {
+		runtime.GC()
+		for _, src := range [][]byte{nil, []byte("hashed by a copy after close")} {
+			if got := copies[i].Hash(src); !bytes.Equal(got, want.Hash(src)) {
+				t.Errorf("copy %d: hash of %x after close = %x, want %x", i, src, got, want.Hash(src))
+			}
+		}
+		if err := copies[i].Close(); err != nil {
+			t.Errorf("closing copy %d: %v", i, err)
+		}
+	}
+}
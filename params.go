@@ -0,0 +1,42 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import "fmt"
+
+// Params are the values that select an LXRHash, as passed to Init
+type Params struct {
+	Seed        uint64 // An arbitrary number used to create the tables
+	MapSizeBits uint64 // Size of the ByteMap in bits
+	HashSize    uint64 // Number of bits in the hash
+	Passes      uint64 // Passes to generate the rand table
+}
+
+// Validate checks the Params are within the bounds the hash supports.  MapSizeBits must be from
+// MinMapSizeBits to MaxMapSizeBits, and small enough to address on this platform.  HashSize
+// must be a whole number of bytes from MinHashSize to MaxHashSize bits, and there must be at
+// least MinPasses passes.
+func (p Params) Validate() error {
+	return p.validate(true)
+}
+
+// validate checks the Params, requiring the ByteMap to be addressable in memory if addressable
+// is set
+func (p Params) validate(addressable bool) error {
+	if p.MapSizeBits < MinMapSizeBits || p.MapSizeBits > MaxMapSizeBits {
+		return fmt.Errorf("MapSizeBits must be between %d and %d, was %d", MinMapSizeBits, MaxMapSizeBits, p.MapSizeBits)
+	}
+	if addressable && p.MapSizeBits > maxAddressableBits {
+		return fmt.Errorf("MapSizeBits of %d cannot be addressed on this platform, the largest is %d", p.MapSizeBits, maxAddressableBits)
+	}
+	if p.HashSize < MinHashSize || p.HashSize > MaxHashSize {
+		return fmt.Errorf("HashSize must be between %d and %d bits, was %d", MinHashSize, MaxHashSize, p.HashSize)
+	}
+	if p.HashSize%8 != 0 {
+		return fmt.Errorf("HashSize must be a whole number of bytes, was %d bits", p.HashSize)
+	}
+	if p.Passes < MinPasses {
+		return fmt.Errorf("Passes must be at least %d, was %d", MinPasses, p.Passes)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package lxr
+
+import (
+	"testing"
+)
+
+func TestParams_Validate(t *testing.T) {
+	largest := MaxMapSizeBits
+	if largest > maxAddressableBits {
+		largest = maxAddressableBits
+	}
+	good := []Params{
+		{Seed, MapSizeBits, HashSize, Passes},
+		{0, MinMapSizeBits, MinHashSize, MinPasses},
+		{Seed, largest, MaxHashSize, 100},
+		{Seed, 16, 136, Passes},
+	}
+	for _, p := range good {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+
+	bad := []Params{
+		{Seed, MinMapSizeBits - 1, HashSize, Passes},
+		{Seed, MaxMapSizeBits + 1, HashSize, Passes},
+		{Seed, 16, 0, Passes},
+		{Seed, 16, 12, Passes},
+		{Seed, 16, MaxHashSize + 8, Passes},
+		{Seed, 16, HashSize, 0},
+	}
+	for _, p := range bad {
+		if err := p.Validate(); err == nil {
+			t.Errorf("%+v: accepted", p)
+		} else {
+			t.Logf("%+v: %v", p, err)
+		}
+	}
+}
+
+func TestInitBounds(t *testing.T) {
+	bad := []Params{
+		{Seed, 7, HashSize, Passes},
+		{Seed, 35, HashSize, Passes},
+		{Seed, 8, 0, Passes},
+		{Seed, 8, 255, Passes},
+		{Seed, 8, HashSize, 0},
+	}
+	for _, p := range bad {
+		for name, init := range map[string]func(){
+			"Init":         func() { Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes) },
+			"LXRHash.Init": func() { new(LXRHash).Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s accepted %+v", name, p)
+					}
+				}()
+				init()
+			}()
+		}
+		if err := new(LXRHash).InitShared(p); err == nil {
+			t.Errorf("InitShared accepted %+v", p)
+		}
+		if _, err := NewDiskHash(p, DiskPageSize); err == nil {
+			t.Errorf("NewDiskHash accepted %+v", p)
+		}
+	}
+}
+
+func TestReleaseHashSize(t *testing.T) {
+	// Every valid HashSize maps back to the same singleton on Release
+	for _, size := range []uint64{8, 64, 136, 512} {
+		one := Init(Seed, 8, size, Passes)
+		if got := uint64(len(one.Hash([]byte("size")))) * 8; got != size {
+			t.Errorf("expected a %d bit hash, got %d bits", size, got)
+		}
+		Release(one)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"encoding/binary"
+	"hash"
+)
+
+// personalMarker starts the personalization block absorbed ahead of the input by
+// PersonalizedHash
+var personalMarker = [4]byte{'L', 'X', 'R', 'P'}
+
+// personalSeed is xored into the Seed to start the state of PersonalizedHash, the marker in
+// the top 4 bytes
+var personalSeed = uint64(binary.BigEndian.Uint32(personalMarker[:])) << 32
+
+// PersonalizedHash returns the hash of src in the domain named by tag, such as "merkle node"
+// or "id".  Each tag gives an independent hash function over the same ByteMap, so one table
+// serves many uses without a hash in one domain colliding with a hash in another, or with
+// Hash.
+//
+// The tag is folded into the state before src is hashed.  The state starts from the Seed
+// xored with the 4 bytes "LXRP" shifted to the top of the 64 bits, and first absorbs the
+// personalization block, which is "LXRP", the length of the tag in bytes as 8 byte big
+// endian, and the tag, with a fast spin and steps of its own.  Only then are src absorbed
+// and the state reduced, just as Hash does.  No input to Hash starts from that state or
+// absorbs in that order, so no input to Hash gives a PersonalizedHash, and the length keeps
+// the split between the tag and src unambiguous.
+func (lx LXRHash) PersonalizedHash(tag, src []byte) []byte {
+	bm := lx.ByteMap
+	inputs := [][]byte{block(personalMarker, tag), src}
+	return hashLookup(lx.Seed^personalSeed, lx.MapSize, lx.HashSize, inputs, func(_ Phase, index uint64) byte { return bm[index] })
+}
+
+// NewPersonalizedHash returns a hash.Hash computing PersonalizedHash with the given tag.  The
+// input is held in memory until Sum.
+func (lx LXRHash) NewPersonalizedHash(tag []byte) hash.Hash {
+	tag = append([]byte{}, tag...)
+	return &hasher{lx: lx, sum: func(src []byte) []byte { return lx.PersonalizedHash(tag, src) }}
+}
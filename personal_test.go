@@ -0,0 +1,75 @@
+package lxr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLXRHash_PersonalizedHash(t *testing.T) {
+	src := []byte("same input in every domain")
+	for _, l := range fastParams() {
+		pow := l.PersonalizedHash([]byte("pow"), src)
+		if len(pow) != int(l.HashSize) {
+			t.Fatalf("%s: got %d bytes, expected %d", paramName(l), len(pow), l.HashSize)
+		}
+		if want := flatPersonalized(l, []byte("LXRP\x00\x00\x00\x00\x00\x00\x00\x03pow"), src); !bytes.Equal(pow, want) {
+			t.Errorf("%s: personalized hash does not follow the personalization block construction", paramName(l))
+		}
+
+		h := l.NewPersonalizedHash([]byte("pow"))
+		h.Write(src[:5])
+		h.Write(src[5:])
+		if got := h.Sum(nil); !bytes.Equal(got, pow) {
+			t.Errorf("%s: NewPersonalizedHash got %x, want %x", paramName(l), got, pow)
+		}
+	}
+}
+
+func TestLXRHash_PersonalizedHashDomains(t *testing.T) {
+	src := []byte("same input in every domain")
+	for _, l := range distinctParams() {
+		pow := l.PersonalizedHash([]byte("pow"), src)
+		domains := map[string][]byte{
+			"hash":         l.Hash(src),
+			"pow":          pow,
+			"merkle":       l.PersonalizedHash([]byte("merkle"), src),
+			"empty tag":    l.PersonalizedHash(nil, src),
+			"key pow":      l.KeyedHash([]byte("pow"), src),
+			"split tag":    l.PersonalizedHash([]byte("po"), append([]byte("w"), src...)),
+			"tag in input": l.PersonalizedHash(nil, append([]byte("pow"), src...)),
+			// Hash of the personalization block followed by the input, and of the block alone
+			"block in input": l.Hash(append([]byte("LXRP\x00\x00\x00\x00\x00\x00\x00\x03pow"), src...)),
+			"block alone":    l.Hash([]byte("LXRP\x00\x00\x00\x00\x00\x00\x00\x03pow")),
+			"pow of nothing": l.PersonalizedHash([]byte("pow"), nil),
+		}
+		for a, ha := range domains {
+			for b, hb := range domains {
+				if a < b && bytes.Equal(ha, hb) {
+					t.Errorf("%s: %s and %s give the same hash %x", paramName(l), a, b, ha)
+				}
+			}
+		}
+	}
+}
+
+// flatPersonalized is PersonalizedHash built on the steps of FlatHash, absorbing the block and
+// then src from the Seed xored with "LXRP" in the top 4 bytes
+func flatPersonalized(lx LXRHash, block, src []byte) []byte {
+	hs := make([]uint64, lx.HashSize)
+	as, s1, s2, s3 := lx.Seed^0x4c58525000000000, uint64(0), uint64(0), uint64(0)
+	mk := lx.MapSize - 1
+	for _, in := range [][]byte{block, src} {
+		for i, v2 := range in {
+			as, s1, s2, s3 = lx.fastStepf(uint64(v2), as, s1, s2, s3, uint64(i)%lx.HashSize, hs)
+		}
+		for i, v2 := range in {
+			as, s1, s2, s3 = lx.stepf(as, s1, s2, s3, uint64(v2), hs, uint64(i)%lx.HashSize, mk)
+		}
+	}
+	out := make([]byte, lx.HashSize)
+	for i := len(hs) - 1; i >= 0; i-- {
+		as, s1, s2, s3 = lx.stepf(as, s1, s2, s3, hs[i], hs, uint64(i), mk)
+		out[i] = lx.ByteMap[as&mk] ^ lx.ByteMap[hs[i]&mk]
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"encoding/binary"
+	"math/rand"
+)
+
+// randTag is the personalization tag of the blocks of Rand
+var randTag = []byte("LXRHash rand")
+
+// Rand is a deterministic, endless stream of random bytes from a seed and the ByteMap of an
+// LXRHash, for reproducible simulations and for feeding RNG test batteries.  It also
+// implements math/rand.Source64, so rand.New(lx.NewRand(seed)) is a seeded *rand.Rand.
+//
+// The stream is made of blocks of HashSize bytes.  Block i is the PersonalizedHash, with
+// the tag "LXRHash rand", of the seed followed by i, both as 8 byte big endian.  Every
+// block is a full hash, so the blocks are independent of each other, and the personalization
+// keeps them apart from every other use of the ByteMap.  A Rand is not safe for concurrent
+// use, and is not meant for cryptographic keys.
+type Rand struct {
+	lx    LXRHash
+	seed  uint64
+	count uint64 // Number of the next block
+	block []byte // The current block of the stream
+	off   int    // Bytes of block already read
+}
+
+var _ rand.Source64 = (*Rand)(nil)
+
+// NewRand returns the stream of random bytes for seed
+func (lx LXRHash) NewRand(seed uint64) *Rand {
+	r := &Rand{lx: lx}
+	r.Seed(int64(seed))
+	return r
+}
+
+// Seed restarts the stream from the start of the stream for seed
+func (r *Rand) Seed(seed int64) {
+	r.seed = uint64(seed)
+	r.count = 0
+	r.block = nil
+	r.off = 0
+}
+
+// next computes the next block of the stream
+func (r *Rand) next() {
+	var src [16]byte
+	binary.BigEndian.PutUint64(src[:8], r.seed)
+	binary.BigEndian.PutUint64(src[8:], r.count)
+	r.block = r.lx.PersonalizedHash(randTag, src[:])
+	r.count++
+	r.off = 0
+}
+
+// Read fills p with the next bytes of the stream.  It always fills p, and never returns an
+// error.
+func (r *Rand) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if r.off == len(r.block) {
+			r.next()
+		}
+		c := copy(p[n:], r.block[r.off:])
+		r.off += c
+		n += c
+	}
+	return n, nil
+}
+
+// Uint64 returns the next 8 bytes of the stream as a big endian uint64
+func (r *Rand) Uint64() uint64 {
+	var b [8]byte
+	r.Read(b[:])
+	return binary.BigEndian.Uint64(b[:])
+}
+
+// Int63 returns the next 8 bytes of the stream as a non-negative int64, dropping the top bit
+func (r *Rand) Int63() int64 {
+	return int64(r.Uint64() >> 1)
+}
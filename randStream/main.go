@@ -0,0 +1,55 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// randStream writes the deterministic random stream of an LXRHash Rand to stdout as raw
+// bytes, for RNG test batteries such as dieharder or PractRand, e.g.
+//
+//	go run ./randStream -seed 1 | RNG_test stdin64
+//	go run ./randStream -seed 1 | dieharder -a -g 200
+//
+// The stream is endless unless -n is given.  Nothing but the stream is written to stdout.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+func main() {
+	seed := flag.Uint64("seed", 0, "seed of the stream")
+	n := flag.Int64("n", 0, "number of bytes to write, or 0 for an endless stream")
+	tableSeed := flag.Uint64("tableseed", lxr.Seed, "seed of the ByteMap")
+	bits := flag.Uint64("bits", lxr.MapSizeBits, "MapSizeBits of the ByteMap")
+	passes := flag.Uint64("passes", lxr.Passes, "Passes to generate the ByteMap")
+	size := flag.Uint64("size", lxr.HashSize, "HashSize in bits, which is the size of the blocks of the stream")
+	flag.Parse()
+
+	p := lxr.Params{Seed: *tableSeed, MapSizeBits: *bits, HashSize: *size, Passes: *passes}
+	if err := p.Validate(); err != nil {
+		fail(err)
+	}
+	lx := new(lxr.LXRHash)
+	lx.Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes)
+
+	var r io.Reader = lx.NewRand(*seed)
+	if *n > 0 {
+		r = io.LimitReader(r, *n)
+	}
+	w := bufio.NewWriterSize(os.Stdout, 1<<16)
+	if _, err := io.Copy(w, r); err != nil {
+		fail(err)
+	}
+	if err := w.Flush(); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "randStream:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,62 @@
+package lxr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+func TestLXRHash_NewRand(t *testing.T) {
+	for _, l := range fastParams() {
+		stream := make([]byte, 3*l.HashSize+5)
+		l.NewRand(42).Read(stream)
+
+		var src [16]byte
+		binary.BigEndian.PutUint64(src[:8], 42)
+		for i := uint64(0); i < 3; i++ {
+			binary.BigEndian.PutUint64(src[8:], i)
+			if want := l.PersonalizedHash([]byte("LXRHash rand"), src[:]); !bytes.Equal(stream[i*l.HashSize:(i+1)*l.HashSize], want) {
+				t.Errorf("%s: block %d is %x, want %x", paramName(l), i, stream[i*l.HashSize:(i+1)*l.HashSize], want)
+			}
+		}
+
+		// Reseeding restarts the stream
+		r := l.NewRand(7)
+		first := r.Uint64()
+		r.Uint64()
+		r.Seed(7)
+		if again := r.Uint64(); again != first {
+			t.Errorf("%s: reseeding gave %x, want %x", paramName(l), again, first)
+		}
+	}
+
+	for _, l := range distinctParams() {
+		if l.NewRand(7).Uint64() == l.NewRand(8).Uint64() {
+			t.Errorf("%s: seeds 7 and 8 start the same", paramName(l))
+		}
+	}
+}
+
+func TestRand_Source64(t *testing.T) {
+	lx := new(LXRHash)
+	lx.Init(Seed, 16, HashSize, Passes)
+
+	a, b := rand.New(lx.NewRand(1)), rand.New(lx.NewRand(1))
+	ones := 0
+	for i := 0; i < 4096; i++ {
+		v := a.Uint64()
+		if v != b.Uint64() {
+			t.Fatal("the same seed gave different streams")
+		}
+		if v := a.Int63(); v < 0 || v != b.Int63() {
+			t.Fatalf("bad Int63 %d", v)
+		}
+		ones += bits.OnesCount64(v)
+	}
+	// About half the bits are set, within 4 standard deviations
+	if total := 4096 * 64; ones < total/2-4*256 || ones > total/2+4*256 {
+		t.Errorf("%d of %d bits set", ones, total)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// SharedDir is the directory InitShared publishes tables in.  It should be on a memory
+// backed file system such as tmpfs, so that the shared table never touches the disk.
+var SharedDir = "/dev/shm"
+
+const (
+	sharedMagic      = uint64(0x314d48534852584c) // "LXRHSHM1" in little endian
+	sharedVersion    = uint32(1)
+	sharedHeaderSize = 4096 // The ByteMap starts on the page following the header
+)
+
+// sharedHeader is the layout of the first page of a shared table.  The segment is only ever
+// shared between processes on the same machine, so the fields are kept in native byte order.
+type sharedHeader struct {
+	Magic       uint64
+	Version     uint32
+	Ready       uint32 // Set once the ByteMap has been written in full
+	Seed        uint64
+	Passes      uint64
+	MapSizeBits uint64
+	MapSize     uint64
+	Refs        int32 // Number of processes attached
+}
+
+// sharedTable is a ByteMap attached by InitShared.  Copies of an LXRHash share it, so it is
+// detached once, by whichever copy is closed first.
+type sharedTable struct {
+	filename string
+	file     *os.File
+	header   []byte // The header page, mapped read and write for the reference count
+	table    []byte // The ByteMap, mapped read only
+
+	detached sync.Once
+	err      error // The error of detaching
+}
+
+// sharedName returns the name of the shared table for the given Params.  The ByteMap does not
+// depend on the HashSize, so all hash sizes share the same table.
+func (p Params) sharedName() string {
+	return fmt.Sprintf("lxrhash-seed-%x-passes-%d-size-%d", p.Seed, p.Passes, p.MapSizeBits)
+}
+
+// hdr returns the header at the start of the mapped page
+func hdr(page []byte) *sharedHeader {
+	return (*sharedHeader)(unsafe.Pointer(&page[0]))
+}
+
+// valid checks the header matches the table of the LXRHash, and the table is complete
+func (h *sharedHeader) valid(lx *LXRHash) error {
+	switch {
+	case h.Magic != sharedMagic:
+		return fmt.Errorf("bad magic %x", h.Magic)
+	case h.Version != sharedVersion:
+		return fmt.Errorf("unsupported version %d", h.Version)
+	case h.Seed != lx.Seed || h.Passes != lx.Passes || h.MapSizeBits != lx.MapSizeBits || h.MapSize != lx.MapSize:
+		return fmt.Errorf("table is for seed %x, passes %d, size %d", h.Seed, h.Passes, h.MapSizeBits)
+	case atomic.LoadUint32(&h.Ready) != 1:
+		return fmt.Errorf("table is incomplete")
+	}
+	return nil
+}
+
+// SharedRefs returns the number of processes attached to the shared table, or zero if the
+// ByteMap was not attached with InitShared
+func (lx *LXRHash) SharedRefs() int {
+	if lx.shared == nil || lx.shared.header == nil {
+		return 0
+	}
+	return int(atomic.LoadInt32(&hdr(lx.shared.header).Refs))
+}
@@ -0,0 +1,249 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// errUnlinked means the shared table was removed by the last process detaching from it
+// between opening the file and locking it
+var errUnlinked = errors.New("shared table was unlinked")
+
+// InitShared initializes the hash with the given Params like Init, but attaches to a ByteMap
+// shared by all processes on the machine, in a file named after the Params in SharedDir.
+//
+// The first process to attach publishes the table, reading it from disk or generating it
+// as Init does.  Later processes map the published table read only after validating its
+// header.  Every attached process holds a shared lock on the file, and Close detaches from
+// it.  The last process to detach removes the file.  Only Lock and Prefault of the
+// LoadOptions apply to a shared table.
+//
+// Copies of the LXRHash share the attachment, and closing any of them detaches them all, so
+// close the LXRHash only once every copy is done hashing.
+func (lx *LXRHash) InitShared(p Params) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	start := time.Now()
+	lx.Close()
+
+	lx.HashSize = (p.HashSize + 7) / 8
+	lx.MapSize = uint64(1) << p.MapSizeBits
+	lx.MapSizeBits = p.MapSizeBits
+	lx.Seed = p.Seed
+	lx.Passes = p.Passes
+
+	filename := filepath.Join(SharedDir, p.sharedName())
+	for {
+		f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return err
+		}
+		s, err := lx.attach(f, filename)
+		if err == errUnlinked {
+			f.Close()
+			continue
+		}
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("shared table %s: %v", filename, err)
+		}
+
+		lx.shared = s
+		lx.ByteMap = s.table
+		lx.stats.Shared = true
+		if lx.options.Prefault {
+			touch(s.table, os.Getpagesize())
+			lx.stats.Prefaulted = true
+		}
+		if lx.options.Lock {
+			if err := syscall.Mlock(s.table); err != nil {
+				lx.Log(fmt.Sprintf("Could not lock the ByteMap into memory: %v", err))
+			} else {
+				lx.stats.Locked = true
+			}
+		}
+		lx.loaded(SourceMapped, start)
+		lx.Log(fmt.Sprintf("Attached to shared ByteMap Table %s, %d processes attached", filename, lx.SharedRefs()))
+		return nil
+	}
+}
+
+// attach locks and maps the opened shared table, publishing it first if no other process
+// is attached to it
+func (lx *LXRHash) attach(f *os.File, filename string) (*sharedTable, error) {
+	fd := int(f.Fd())
+
+	// Only one process can hold the exclusive lock, and only while no other process is
+	// attached, so whoever gets it may (re)build the table.  Everyone else waits for a
+	// shared lock, which is only granted once the publisher has finished.
+	publish := true
+	if err := syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if err != syscall.EWOULDBLOCK {
+			return nil, err
+		}
+		publish = false
+		if err := syscall.Flock(fd, syscall.LOCK_SH); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := linked(f, filename); err != nil || !ok {
+		if err == nil {
+			err = errUnlinked
+		}
+		return nil, err
+	}
+
+	size := int64(sharedHeaderSize) + int64(lx.MapSize)
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !publish && info.Size() != size {
+		return nil, fmt.Errorf("file is %d bytes, expected %d", info.Size(), size)
+	}
+	if publish && info.Size() != size {
+		if err := f.Truncate(0); err != nil {
+			return nil, err
+		}
+		if err := f.Truncate(size); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &sharedTable{filename: filename, file: f}
+	s.header, err = syscall.Mmap(fd, 0, sharedHeaderSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	h := hdr(s.header)
+
+	if publish && h.valid(lx) != nil {
+		if err := lx.publish(s, h); err != nil {
+			s.unmap()
+			return nil, err
+		}
+	}
+	if err := h.valid(lx); err != nil {
+		s.unmap()
+		return nil, err
+	}
+	if s.table == nil {
+		s.table, err = syscall.Mmap(fd, sharedHeaderSize, int(lx.MapSize), syscall.PROT_READ, syscall.MAP_SHARED)
+		if err != nil {
+			s.unmap()
+			return nil, err
+		}
+	}
+
+	if publish {
+		if err := syscall.Flock(fd, syscall.LOCK_SH); err != nil {
+			s.unmap()
+			return nil, err
+		}
+	}
+	atomic.AddInt32(&h.Refs, 1)
+	return s, nil
+}
+
+// publish writes the header and the ByteMap into the shared table.  The table is marked
+// ready only once it is complete, so a publisher that dies part way is detected.  The ByteMap
+// is read or generated into private memory first and then copied, so publishing briefly takes
+// twice the memory of the table.
+func (lx *LXRHash) publish(s *sharedTable, h *sharedHeader) error {
+	lx.Log(fmt.Sprintf("Publishing shared ByteMap Table %s", s.filename))
+	atomic.StoreUint32(&h.Ready, 0)
+	h.Magic = sharedMagic
+	h.Version = sharedVersion
+	h.Seed = lx.Seed
+	h.Passes = lx.Passes
+	h.MapSizeBits = lx.MapSizeBits
+	h.MapSize = lx.MapSize
+	h.Refs = 0
+
+	table, err := syscall.Mmap(int(s.file.Fd()), sharedHeaderSize, int(lx.MapSize), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	s.table = table
+
+	// Load the table the usual way, from the disk cache if possible, into private memory
+	private := LXRHash{Seed: lx.Seed, MapSize: lx.MapSize, MapSizeBits: lx.MapSizeBits, Passes: lx.Passes, verbose: lx.verbose}
+	if err := private.readTable(context.Background()); err != nil {
+		return err
+	}
+	copy(table, private.ByteMap)
+	private.Close()
+
+	if err := syscall.Mprotect(table, syscall.PROT_READ); err != nil {
+		return err
+	}
+	atomic.StoreUint32(&h.Ready, 1)
+	return nil
+}
+
+// linked checks the opened file is still the one named filename
+func linked(f *os.File, filename string) (bool, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	named, err := os.Stat(filename)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(info, named), nil
+}
+
+// unmap releases the mappings of the shared table
+func (s *sharedTable) unmap() {
+	if s.table != nil {
+		syscall.Munmap(s.table)
+		s.table = nil
+	}
+	if s.header != nil {
+		syscall.Munmap(s.header)
+		s.header = nil
+	}
+}
+
+// detach releases the shared table, and removes it if no other process is attached.  Only
+// the first call detaches, later ones return the same error.
+func (s *sharedTable) detach() error {
+	s.detached.Do(func() {
+		atomic.AddInt32(&hdr(s.header).Refs, -1)
+		s.unmap()
+
+		// Whoever can upgrade to the exclusive lock is the last one attached
+		if err := syscall.Flock(int(s.file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err == nil {
+			if ok, _ := linked(s.file, s.filename); ok {
+				os.Remove(s.filename)
+			}
+		}
+		s.err = s.file.Close()
+	})
+	return s.err
+}
+
+// touch reads every page of the table
+func touch(table []byte, pageSize int) {
+	var sum byte
+	for i := 0; i < len(table); i += pageSize {
+		sum += table[i]
+	}
+	sink = sum
+}
+
+// sink keeps the reads of touch from being optimized away
+var sink byte
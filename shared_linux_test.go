@@ -0,0 +1,123 @@
+package lxr
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func sharedDir(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "lxrshm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := SharedDir
+	SharedDir = dir
+	return func() {
+		SharedDir = old
+		os.RemoveAll(dir)
+	}
+}
+
+func TestLXRHash_InitShared(t *testing.T) {
+	defer sharedDir(t)()
+
+	p := Params{Seed: Seed, MapSizeBits: 16, HashSize: HashSize, Passes: Passes}
+	filename := filepath.Join(SharedDir, p.sharedName())
+
+	want := new(LXRHash)
+	want.Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes)
+
+	one, two := new(LXRHash), new(LXRHash)
+	two.SetLoadOptions(LoadOptions{Lock: true, Prefault: true})
+	if err := one.InitShared(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := two.InitShared(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if one.Source() != SourceMapped || one.Digest() != want.Digest() {
+		t.Errorf("expected a mapped table with digest %x, got %s with %x", want.Digest(), one.Source(), one.Digest())
+	}
+	if refs := two.SharedRefs(); refs != 2 {
+		t.Errorf("expected 2 references, got %d", refs)
+	}
+	if !two.LoadStats().Shared || !two.LoadStats().Prefaulted {
+		t.Errorf("unexpected stats %+v", two.LoadStats())
+	}
+	if unsafe.Pointer(&one.ByteMap[0]) == unsafe.Pointer(&two.ByteMap[0]) {
+		t.Error("both instances use the same mapping")
+	}
+	if !bytes.Equal(one.ByteMap, want.ByteMap) || !bytes.Equal(two.ByteMap, want.ByteMap) {
+		t.Fatal("shared ByteMap differs from the one loaded by Init")
+	}
+	src := []byte("shared between processes")
+	if !bytes.Equal(one.Hash(src), want.Hash(src)) || !bytes.Equal(two.Hash(src), want.Hash(src)) {
+		t.Error("shared hash differs from the one loaded by Init")
+	}
+
+	// Closing a copy after the original detaches only once
+	copied := *one
+	if err := one.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := copied.Close(); err != nil {
+		t.Errorf("closing a copy: %v", err)
+	}
+	if refs := two.SharedRefs(); refs != 1 {
+		t.Errorf("expected 1 reference, got %d", refs)
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Errorf("shared table removed while still attached: %v", err)
+	}
+	if err := two.Close(); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("shared table not removed by the last process to detach: %v", err)
+	}
+}
+
+func TestLXRHash_InitSharedHeader(t *testing.T) {
+	defer sharedDir(t)()
+
+	p := Params{Seed: Seed, MapSizeBits: 12, HashSize: HashSize, Passes: Passes}
+	filename := filepath.Join(SharedDir, p.sharedName())
+
+	want := new(LXRHash)
+	want.Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes)
+
+	// A stale table left by a process that died is rebuilt by the next one to attach
+	if err := ioutil.WriteFile(filename, bytes.Repeat([]byte{0xff}, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+	one := new(LXRHash)
+	if err := one.InitShared(p); err != nil {
+		t.Fatal(err)
+	}
+	defer one.Close()
+	if !bytes.Equal(one.ByteMap, want.ByteMap) {
+		t.Fatal("stale shared table was not rebuilt")
+	}
+
+	// A table in use with a bad header is rejected
+	hdr(one.shared.header).Version++
+	two := new(LXRHash)
+	if err := two.InitShared(p); err == nil {
+		two.Close()
+		t.Error("attached to a table with a bad header")
+	}
+	hdr(one.shared.header).Version--
+	if err := two.InitShared(p); err != nil {
+		t.Error(err)
+	}
+	two.Close()
+
+	if err := new(LXRHash).InitShared(Params{Seed: Seed, MapSizeBits: 4, HashSize: HashSize, Passes: Passes}); err == nil {
+		t.Error("accepted a map size of 4 bits")
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+//go:build !linux
+// +build !linux
+
+package lxr
+
+import "errors"
+
+// InitShared attaches to a ByteMap shared by all processes on the machine.  Shared tables
+// are only supported on Linux.
+func (lx *LXRHash) InitShared(p Params) error {
+	return errors.New("shared tables are only supported on Linux")
+}
@@ -49,7 +49,6 @@ func mine(useLXR bool, data []byte) uint64 {
 
 		}
 	}
-	return cd
 }
 
 func main() {
@@ -59,7 +58,8 @@ func main() {
 			"simMiner <hash> [bits]\n\n" +
 			"<hash> is equal to LXRHash to sim mine LXRHash\n" +
 			"<hash> is equal to Sha256 to sim mine Sha256\n" +
-			"[bits] defaults to 30, but lower numbers can be quicker to initialize")
+			fmt.Sprintf("[bits] defaults to %d, but lower numbers can be quicker to initialize.  It must be between %d and %d",
+				lxr.MapSizeBits, lxr.MinMapSizeBits, lxr.MaxMapSizeBits))
 		os.Exit(0)
 	}
 
@@ -76,15 +76,17 @@ func main() {
 	bits := lxr.MapSizeBits
 	if hash {
 		if len(os.Args) == 3 {
-			b, err := strconv.Atoi(os.Args[2])
+			b, err := strconv.ParseUint(os.Args[2], 10, 64)
 			if err != nil {
 				fmt.Println(err)
 				leave()
 			}
-			if b > 40 || b < 8 {
-				fmt.Println("Bits specified must be at least 8 and less than or equal to 40.  40 bits is 1 TB")
-			}
-			bits = uint64(b)
+			bits = b
+		}
+		p := lxr.Params{Seed: lxr.Seed, MapSizeBits: bits, HashSize: lxr.HashSize, Passes: lxr.Passes}
+		if err := p.Validate(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
 
 		LX = new(lxr.LXRHash)
@@ -1,45 +1,322 @@
 package lxr
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // The goal of instances is to provide a way for multiple packages to use LXR without
-// instantiating multiple bytemaps in memory or having to share references
+// instantiating multiple bytemaps in memory or having to share references.
+//
+// Tables are loaded outside of instanceMtx, so loading one table does not block callers
+// that want another.  Callers that want a table that is still loading wait for the one load.
 
 var instanceMtx sync.Mutex
-var instances map[string]*LXRHash
-var counter map[string]uint64
+var instances = make(map[Params]*instance)
 
-func init() {
-	instances = make(map[string]*LXRHash)
-	counter = make(map[string]uint64)
+// With a memory budget, tables no longer referenced are kept until their memory is needed
+var budget uint64    // Memory budget in bytes, or zero for none
+var resident uint64  // Bytes of the tables in the registry
+var evictions uint64 // Tables evicted to stay within the budget
+
+// ErrReleased is returned when releasing a Handle that was already released
+var ErrReleased = errors.New("lxrhash: handle already released")
+
+// ErrMemoryBudget is returned by Acquire when loading a table would exceed the memory budget
+var ErrMemoryBudget = errors.New("lxrhash: memory budget exceeded")
+
+// instance is a table in the registry
+type instance struct {
+	params Params
+	lx     *LXRHash
+	refs   int           // Handles and callers waiting for the load
+	ready  chan struct{} // Closed once the load is done
+	loaded bool          // The load succeeded
+	err    error         // Why the load failed
+	cancel context.CancelFunc
+
+	bytes    uint64    // Size of the table
+	acquires uint64    // Number of calls to Acquire for the table
+	lastUsed time.Time // When the table was last acquired or released
 }
 
-// Init provides access to shared instances of LXRHash without having to instantiate multiple bytemaps.
-// Two separate calls to Init() will result in a reference to the same object.
-func Init(seed, bitsize, hashsize, passes uint64) *LXRHash {
-	if bitsize < 8 {
-		panic("bitsize must be at least 8")
+// Handle is a reference to a shared LXRHash acquired from the registry.  The LXRHash must not
+// be used once the handle is released.
+type Handle struct {
+	instance *instance
+	released int32
+}
+
+// LXRHash returns the shared LXRHash
+func (h *Handle) LXRHash() *LXRHash {
+	return h.instance.lx
+}
+
+// Release releases the reference to the shared LXRHash.  Once all references to it are
+// released, it is removed from the registry and can be garbage collected, or with a memory
+// budget, kept until it is evicted.
+func (h *Handle) Release() error {
+	if !atomic.CompareAndSwapInt32(&h.released, 0, 1) {
+		return ErrReleased
+	}
+	instanceMtx.Lock()
+	defer instanceMtx.Unlock()
+	h.instance.release()
+	return nil
+}
+
+// Acquire returns a handle to the shared LXRHash for the given Params, loading its table if
+// it is not loaded yet.  Concurrent calls for the same Params wait for the same load.
+//
+// If ctx is done before the table is loaded, Acquire returns the error of ctx.  The load
+// carries on for any other callers waiting for it, and is cancelled once none are left.
+func Acquire(ctx context.Context, p Params) (*Handle, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+
+	instanceMtx.Lock()
+	in, ok := instances[p]
+	if !ok {
+		size := uint64(1) << p.MapSizeBits
+		if err := reserve(size); err != nil {
+			instanceMtx.Unlock()
+			return nil, fmt.Errorf("%w: loading %d bytes for %+v", err, size, p)
+		}
+		var loadCtx context.Context
+		in = &instance{params: p, ready: make(chan struct{}), bytes: size}
+		loadCtx, in.cancel = context.WithCancel(context.Background())
+		instances[p] = in
+		resident += size
+		go in.load(loadCtx)
+	}
+	in.refs++
+	in.acquires++
+	in.lastUsed = time.Now()
+	instanceMtx.Unlock()
+
+	select {
+	case <-in.ready:
+	case <-ctx.Done():
+		instanceMtx.Lock()
+		in.release()
+		instanceMtx.Unlock()
+		return nil, ctx.Err()
+	}
+
+	if in.err != nil {
+		instanceMtx.Lock()
+		in.release()
+		instanceMtx.Unlock()
+		return nil, in.err
+	}
+	return &Handle{instance: in}, nil
+}
+
+// load loads the table of the instance, and wakes the callers waiting for it
+func (in *instance) load(ctx context.Context) {
+	lx := new(LXRHash)
+	lx.Verbose(true)
+	err := lx.initContext(ctx, in.params)
+
+	instanceMtx.Lock()
+	defer instanceMtx.Unlock()
+	in.cancel()
+	if err != nil {
+		in.err = err
+		in.remove()
+	} else {
+		in.lx = lx
+		in.loaded = true
+	}
+	close(in.ready)
+}
+
+// release drops a reference to the instance.  Once no references are left, an unfinished
+// load is cancelled, and the instance is removed from the registry unless there is a memory
+// budget to keep it within.  instanceMtx must be held.
+func (in *instance) release() {
+	in.refs--
+	in.lastUsed = time.Now()
+	if in.refs == 0 && (!in.loaded || budget == 0) {
+		in.cancel()
+		in.remove()
+	}
+}
+
+// remove removes the instance from the registry.  instanceMtx must be held.
+func (in *instance) remove() {
+	if instances[in.params] == in {
+		delete(instances, in.params)
+		resident -= in.bytes
 	}
+}
+
+// reserve makes room for a table of size bytes within the memory budget, evicting the least
+// recently used tables that are no longer referenced.  instanceMtx must be held.
+func reserve(size uint64) error {
+	if budget == 0 {
+		return nil
+	}
+	if size > budget {
+		return ErrMemoryBudget
+	}
+	evict(budget - size)
+	if resident+size > budget {
+		return ErrMemoryBudget
+	}
+	return nil
+}
+
+// evict removes the least recently used tables that are no longer referenced until at most
+// limit bytes are resident.  instanceMtx must be held.
+func evict(limit uint64) {
+	var idle []*instance
+	for _, in := range instances {
+		if in.refs == 0 && in.loaded {
+			idle = append(idle, in)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].lastUsed.Before(idle[j].lastUsed) })
+	for _, in := range idle {
+		if resident <= limit {
+			return
+		}
+		in.remove()
+		evictions++
+	}
+}
 
+// SetMemoryBudget limits the memory of the tables in the registry to the given number of
+// bytes, or removes the limit if it is zero.
+//
+// Without a budget, a table is removed from the registry as soon as it is no longer
+// referenced.  With a budget, it is kept until the memory is needed for another table, when
+// the least recently used tables are evicted first.  Acquire fails with ErrMemoryBudget if
+// the tables still referenced leave no room for the table asked for.
+func SetMemoryBudget(bytes uint64) {
 	instanceMtx.Lock()
 	defer instanceMtx.Unlock()
+	budget = bytes
+	if budget == 0 {
+		evict(0)
+	} else {
+		evict(budget)
+	}
+}
 
-	id := fmt.Sprintf("%d-%d-%d-%d", seed, bitsize, hashsize, passes)
+// RegistryStats describes the tables in the singleton registry
+type RegistryStats struct {
+	Budget    uint64       // Memory budget in bytes, or zero if there is none
+	Resident  uint64       // Bytes of the tables in the registry, including those loading
+	Evictions uint64       // Number of tables evicted to stay within the budget
+	Tables    []TableStats // The tables in the registry
+}
 
-	counter[id]++
+// TableStats describes a table in the singleton registry
+type TableStats struct {
+	Params   Params
+	Bytes    uint64    // Size of the table
+	Refs     int       // References to the table, including callers waiting for it to load
+	Loading  bool      // The table is still being loaded
+	Acquires uint64    // Number of calls to Acquire or Init for the table
+	LastUsed time.Time // When the table was last acquired or released
+}
+
+// Stats returns the statistics of the singleton registry, with the tables in the order of
+// their Params
+func Stats() RegistryStats {
+	stats, _ := snapshot()
+	return stats
+}
+
+// Instance describes a table in the singleton registry, along with its digest
+type Instance struct {
+	TableStats
+	Digest [32]byte // SHA-256 of the ByteMap, or zero while the table is loading
+}
 
-	if instance, ok := instances[id]; ok {
-		return instance
+// Instances lists the tables in the singleton registry in the order of their Params.  The
+// digest of a table is computed the first time it is asked for, which takes a few seconds
+// for a 1GB table.
+func Instances() []Instance {
+	_, list := Snapshot()
+	return list
+}
+
+// Snapshot returns both the Stats and the Instances of the singleton registry, taken at the
+// same time so that they agree
+func Snapshot() (RegistryStats, []Instance) {
+	stats, tables := snapshot()
+	list := make([]Instance, len(stats.Tables))
+	for i, table := range stats.Tables {
+		list[i].TableStats = table
+		if tables[i] != nil {
+			list[i].Digest = tables[i].Digest()
+		}
 	}
+	return stats, list
+}
 
-	lxr := new(LXRHash)
-	lxr.Verbose(true)
-	lxr.Init(seed, bitsize, hashsize, passes)
-	instances[id] = lxr
-	return lxr
+// snapshot returns the statistics of the singleton registry, and the LXRHash of each of its
+// tables, or nil for those still loading
+func snapshot() (RegistryStats, []*LXRHash) {
+	instanceMtx.Lock()
+	defer instanceMtx.Unlock()
+
+	list := make([]*instance, 0, len(instances))
+	for _, in := range instances {
+		list = append(list, in)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].params, list[j].params
+		switch {
+		case a.Seed != b.Seed:
+			return a.Seed < b.Seed
+		case a.MapSizeBits != b.MapSizeBits:
+			return a.MapSizeBits < b.MapSizeBits
+		case a.Passes != b.Passes:
+			return a.Passes < b.Passes
+		}
+		return a.HashSize < b.HashSize
+	})
+
+	stats := RegistryStats{Budget: budget, Resident: resident, Evictions: evictions}
+	tables := make([]*LXRHash, len(list))
+	for i, in := range list {
+		stats.Tables = append(stats.Tables, TableStats{
+			Params:   in.params,
+			Bytes:    in.bytes,
+			Refs:     in.refs,
+			Loading:  !in.loaded,
+			Acquires: in.acquires,
+			LastUsed: in.lastUsed,
+		})
+		tables[i] = in.lx
+	}
+	return stats, tables
+}
+
+// Init provides access to shared instances of LXRHash without having to instantiate multiple bytemaps.
+// Two separate calls to Init() will result in a reference to the same object.
+//
+// Init panics if the parameters are out of the bounds checked by Params.Validate.
+func Init(seed, bitsize, hashsize, passes uint64) *LXRHash {
+	p := Params{Seed: seed, MapSizeBits: bitsize, HashSize: hashsize, Passes: passes}
+	if err := p.Validate(); err != nil {
+		panic(fmt.Sprintf("Bad LXRHash parameters: %v", err))
+	}
+
+	h, err := Acquire(context.Background(), p)
+	if err != nil {
+		panic(err)
+	}
+	return h.LXRHash()
 }
 
 // Release releases a singleton. If all references to the singleton have been released, the singleton is destroyed
@@ -51,14 +328,12 @@ func Release(hash *LXRHash) {
 	instanceMtx.Lock()
 	defer instanceMtx.Unlock()
 
-	id := fmt.Sprintf("%d-%d-%d-%d", hash.Seed, hash.MapSizeBits, hash.HashSize*8, hash.Passes)
-	test, exists := instances[id]
-	if !exists || test != hash {
+	in, exists := instances[hash.Params()]
+	if !exists || in.lx != hash {
 		panic("tried to release a non-singleton instance")
 	}
-	counter[id]--
-	if counter[id] == 0 {
-		delete(counter, id)
-		delete(instances, id)
+	if in.refs == 0 {
+		panic("tried to release a singleton with no references")
 	}
+	in.release()
 }
@@ -2,8 +2,12 @@ package lxr
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"testing"
+	"time"
 )
 
 func testSize(t *testing.T, bits uint64, buf []byte, reference string) {
@@ -73,3 +77,165 @@ func TestRelease(t *testing.T) {
 		t.Errorf("original singleton was destroyed during release")
 	}
 }
+
+func TestAcquire(t *testing.T) {
+	p := Params{Seed: Seed, MapSizeBits: 8, HashSize: HashSize, Passes: Passes}
+
+	// Concurrent requests share one instance
+	handles := make(chan *Handle, 8)
+	for i := 0; i < cap(handles); i++ {
+		go func() {
+			h, err := Acquire(context.Background(), p)
+			if err != nil {
+				t.Error(err)
+			}
+			handles <- h
+		}()
+	}
+	first := <-handles
+	for i := 1; i < cap(handles); i++ {
+		h := <-handles
+		if h.LXRHash() != first.LXRHash() {
+			t.Error("concurrent Acquire returned separate instances")
+		}
+		if err := h.Release(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The old API shares the same registry
+	old := Init(Seed, 8, HashSize, Passes)
+	if old != first.LXRHash() {
+		t.Error("Init returned a separate instance from Acquire")
+	}
+	Release(old)
+
+	if err := first.Release(); err != nil {
+		t.Error(err)
+	}
+	if err := first.Release(); err != ErrReleased {
+		t.Errorf("expected %v on a double release, got %v", ErrReleased, err)
+	}
+
+	if _, err := Acquire(context.Background(), Params{Seed: Seed, MapSizeBits: 8, HashSize: 12, Passes: Passes}); err == nil {
+		t.Error("acquired invalid params")
+	}
+}
+
+func TestAcquireCancel(t *testing.T) {
+	// A table that is not cached, and takes a while to generate
+	p := Params{Seed: uint64(time.Now().UnixNano()), MapSizeBits: 24, HashSize: HashSize, Passes: Passes}
+	l := &LXRHash{Seed: p.Seed, MapSizeBits: p.MapSizeBits, Passes: p.Passes}
+	filename, _, err := l.tableFilename()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(filename)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		h, err := Acquire(ctx, p)
+		if err == nil {
+			h.Release()
+		}
+		done <- err
+	}()
+
+	// Tables already loaded are not held up by the one being generated
+	for instanceCount(p) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	quick, qcancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer qcancel()
+	h, err := Acquire(quick, Params{Seed: Seed, MapSizeBits: 8, HashSize: HashSize, Passes: Passes})
+	if err != nil {
+		t.Fatalf("blocked by a table being generated: %v", err)
+	}
+	h.Release()
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	// The load is abandoned once nobody waits for it
+	for instanceCount(p) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("cancelled table was written to %s", filename)
+	}
+}
+
+func TestSetMemoryBudget(t *testing.T) {
+	params := func(bits, hashSize uint64) Params {
+		return Params{Seed: 0xb0d6e7, MapSizeBits: bits, HashSize: hashSize, Passes: Passes}
+	}
+	acquire := func(p Params) *Handle {
+		h, err := Acquire(context.Background(), p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	// Other tests may leave tables referenced, which count against the budget
+	before := Stats()
+	SetMemoryBudget(before.Resident + 1536)
+	defer SetMemoryBudget(0)
+
+	// Released tables are kept while they fit in the budget
+	small := acquire(params(8, HashSize))
+	lx8 := small.LXRHash()
+	small.Release()
+	if again := acquire(params(8, HashSize)); again.LXRHash() != lx8 {
+		t.Error("released table was not kept within the budget")
+	} else {
+		again.Release()
+	}
+
+	// and the least recently used are evicted to make room for others
+	large := acquire(params(10, HashSize))
+	medium := acquire(params(9, HashSize))
+	stats := Stats()
+	if instanceCount(params(8, HashSize)) != 0 || stats.Evictions != before.Evictions+1 {
+		t.Errorf("expected the 8 bit table to be evicted, got %+v", stats)
+	}
+	if stats.Budget != before.Resident+1536 || stats.Resident != stats.Budget || len(stats.Tables) != len(before.Tables)+2 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	for _, table := range stats.Tables {
+		if table.Params.Seed == 0xb0d6e7 && table.Refs != 1 || table.Loading || table.Bytes != uint64(1)<<table.Params.MapSizeBits {
+			t.Errorf("unexpected table stats %+v", table)
+		}
+	}
+
+	// Tables still referenced are never evicted
+	if _, err := Acquire(context.Background(), params(9, 128)); !errors.Is(err, ErrMemoryBudget) {
+		t.Errorf("expected %v, got %v", ErrMemoryBudget, err)
+	}
+	medium.Release()
+	other := acquire(params(9, 128))
+	if instanceCount(params(9, HashSize)) != 0 {
+		t.Error("expected the released 9 bit table to be evicted")
+	}
+	other.Release()
+	large.Release()
+
+	// Dropping the budget drops the released tables
+	SetMemoryBudget(0)
+	if stats := Stats(); stats.Resident != before.Resident || len(stats.Tables) != len(before.Tables) {
+		t.Errorf("released tables kept without a budget: %+v", stats)
+	}
+}
+
+// instanceCount returns the number of instances registered for p
+func instanceCount(p Params) int {
+	instanceMtx.Lock()
+	defer instanceMtx.Unlock()
+	if _, ok := instances[p]; ok {
+		return 1
+	}
+	return 0
+}
@@ -4,8 +4,11 @@ package lxr
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"os/user"
 	"time"
@@ -19,6 +22,14 @@ const (
 	firstv    = uint64(3523455478921636871)
 )
 
+// tableMagic starts the header of a table written by ExportTable
+var tableMagic = []byte("LXRTABLE")
+
+const (
+	tableVersion    = 1  // Version of the format written by ExportTable
+	tableHeaderSize = 48 // Size of the header written by ExportTable
+)
+
 // Verbose enables or disables the output of progress indicators to the console
 func (lx *LXRHash) Verbose(val bool) {
 	lx.verbose = val
@@ -39,53 +50,112 @@ func (lx *LXRHash) Log(msg string) {
 //
 // Seed is a 64 bit starting point
 // MapSizeBits is the number of bits to use for the MapSize, i.e. 10 = mapsize of 1024
-// HashSize is the number of bits in the hash; truncated to a byte bountry
+// HashSize is the number of bits in the hash, which must be a whole number of bytes
 // Passes is the number of shuffles of the ByteMap performed.  Each pass shuffles all byte values in the map
+//
+// Init panics if the parameters are out of the bounds checked by Params.Validate
 func (lx *LXRHash) Init(Seed, MapSizeBits, HashSize, Passes uint64) {
-	if MapSizeBits < 8 {
-		panic(fmt.Sprintf("Bad Map Size in Bits.  Must be between 8 and 34 bits, was %d", MapSizeBits))
+	if err := (Params{Seed, MapSizeBits, HashSize, Passes}).Validate(); err != nil {
+		panic(fmt.Sprintf("Bad LXRHash parameters: %v", err))
 	}
 
-	MapSize := uint64(1) << MapSizeBits
-	lx.HashSize = (HashSize + 7) / 8
-	lx.MapSize = MapSize
-	lx.MapSizeBits = MapSizeBits
-	lx.Seed = Seed
-	lx.Passes = Passes
-	lx.ReadTable()
+	if err := lx.initContext(context.Background(), Params{Seed, MapSizeBits, HashSize, Passes}); err != nil {
+		panic(err)
+	}
+}
 
+// initContext initializes the hash with the given Params like Init.  If the table has to be
+// generated, generation stops and the error of ctx is returned once ctx is done.
+func (lx *LXRHash) initContext(ctx context.Context, p Params) error {
+	lx.HashSize = (p.HashSize + 7) / 8
+	lx.MapSize = uint64(1) << p.MapSizeBits
+	lx.MapSizeBits = p.MapSizeBits
+	lx.Seed = p.Seed
+	lx.Passes = p.Passes
+	return lx.readTable(ctx)
 }
 
 // ReadTable attempts to load the ByteMap from disk.
 // If that doesn't exist, a new one will be generated and saved.
 func (lx *LXRHash) ReadTable() {
-	u, err := user.Current()
-	if err != nil {
+	if err := lx.readTable(context.Background()); err != nil {
 		panic(err)
 	}
-	userPath := u.HomeDir
-	lxrhashPath := userPath + "/.lxrhash"
-	err = os.MkdirAll(lxrhashPath, os.ModePerm)
+}
+
+// readTable is ReadTable, which stops generating the table once ctx is done
+func (lx *LXRHash) readTable(ctx context.Context) error {
+	filename, legacy, err := lx.tableFilename()
 	if err != nil {
-		panic(fmt.Sprintf("Could not create the directory %s", lxrhashPath))
+		return err
 	}
-
-	filename := fmt.Sprintf(lxrhashPath+"/lxrhash-seed-%x-passes-%d-size-%d.dat", lx.Seed, lx.Passes, lx.MapSizeBits)
-	// Try and load our byte map.
+	// Try and load our byte map, or else the one cached before headers were added.
 	lx.Log(fmt.Sprintf("Reading ByteMap Table %s", filename))
 
 	start := time.Now()
-	dat, err := ioutil.ReadFile(filename)
+	err = lx.readFile(filename)
+	if err != nil {
+		err = lx.readFile(legacy)
+	}
 	// If loading fails, or it is the wrong size, generate it.  Otherwise just use it.
-	if err != nil || len(dat) != int(lx.MapSize) {
+	if err != nil {
 		lx.Log("Table not found, Generating ByteMap Table")
-		lx.GenerateTable()
+		if err := lx.generateTable(ctx); err != nil {
+			return err
+		}
 		lx.Log("Writing ByteMap Table ")
 		lx.WriteTable(filename)
-	} else {
-		lx.ByteMap = dat
 	}
 	lx.Log(fmt.Sprintf("Finished Reading ByteMap Table. Total time taken: %s", time.Since(start)))
+	return nil
+}
+
+// tableFilename returns the name of the file the ByteMap is cached in, with the header written
+// by ExportTable, creating the directory for it if needed.  The legacy file is where tables
+// were cached without a header.  Releases before headers were added read that file as the
+// bare table, so it is still read but never written.
+func (lx *LXRHash) tableFilename() (filename, legacy string, err error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", "", err
+	}
+	userPath := u.HomeDir
+	lxrhashPath := userPath + "/.lxrhash"
+	err = os.MkdirAll(lxrhashPath, os.ModePerm)
+	if err != nil {
+		return "", "", fmt.Errorf("Could not create the directory %s", lxrhashPath)
+	}
+
+	name := fmt.Sprintf(lxrhashPath+"/lxrhash-seed-%x-passes-%d-size-%d", lx.Seed, lx.Passes, lx.MapSizeBits)
+	return name + ".table", name + ".dat", nil
+}
+
+// readFile reads the ByteMap from the given file.  Tables written before headers were added
+// are accepted, provided they are exactly MapSize bytes.
+func (lx *LXRHash) readFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	switch info.Size() {
+	case int64(lx.MapSize):
+		start := time.Now()
+		lx.ByteMap = lx.allocTable()
+		if _, err = io.ReadFull(f, lx.ByteMap); err != nil {
+			return err
+		}
+		lx.loaded(SourceLoaded, start)
+		return nil
+	case tableHeaderSize + int64(lx.MapSize):
+		return lx.LoadTable(bufio.NewReader(f))
+	}
+	return fmt.Errorf("table %s is %d bytes, expected %d", filename, info.Size(), tableHeaderSize+lx.MapSize)
 }
 
 // WriteTable caches the bytemap to disk so it only has to be generated once
@@ -104,17 +174,9 @@ func (lx *LXRHash) WriteTable(filename string) {
 		}
 	}()
 
-	// write a chunk
 	w := bufio.NewWriter(fo)
-	bufSize := 4096 // 4KiB
-	for i := 0; i < len(lx.ByteMap); i += bufSize {
-		j := i + bufSize
-		if j > len(lx.ByteMap) {
-			j = len(lx.ByteMap)
-		}
-		if nn, err := w.Write(lx.ByteMap[i:j]); err != nil {
-			panic(fmt.Sprintf("error writing bytemap to disk: %d bytes written, %v", nn, err))
-		}
+	if err := lx.ExportTable(w); err != nil {
+		panic(fmt.Sprintf("error writing bytemap to disk: %v", err))
 	}
 	err = w.Flush()
 	if err != nil {
@@ -122,11 +184,113 @@ func (lx *LXRHash) WriteTable(filename string) {
 	}
 }
 
+// tableHeader returns the header ExportTable writes before the ByteMap.  It holds tableMagic,
+// the version of the format, and the Seed, Passes, MapSizeBits and MapSize of the table, all
+// in big endian.
+func (lx *LXRHash) tableHeader() []byte {
+	h := make([]byte, tableHeaderSize)
+	copy(h, tableMagic)
+	binary.BigEndian.PutUint32(h[8:], tableVersion)
+	binary.BigEndian.PutUint64(h[16:], lx.Seed)
+	binary.BigEndian.PutUint64(h[24:], lx.Passes)
+	binary.BigEndian.PutUint64(h[32:], lx.MapSizeBits)
+	binary.BigEndian.PutUint64(h[40:], lx.MapSize)
+	return h
+}
+
+// checkTableHeader validates a header written by ExportTable, and returns the Seed, Passes
+// and MapSizeBits of the table.  If the MapSizeBits of the LXRHash are set, the table must
+// match its Seed, Passes and MapSizeBits.
+func (lx *LXRHash) checkTableHeader(h []byte) (seed, passes, bits uint64, err error) {
+	if !bytes.Equal(h[:8], tableMagic) {
+		return 0, 0, 0, fmt.Errorf("not an LXRHash table, bad magic %x", h[:8])
+	}
+	if version := binary.BigEndian.Uint32(h[8:]); version != tableVersion {
+		return 0, 0, 0, fmt.Errorf("unsupported table version %d", version)
+	}
+	seed = binary.BigEndian.Uint64(h[16:])
+	passes = binary.BigEndian.Uint64(h[24:])
+	bits = binary.BigEndian.Uint64(h[32:])
+	if size := binary.BigEndian.Uint64(h[40:]); bits < MinMapSizeBits || bits > MaxMapSizeBits || size != uint64(1)<<bits {
+		return 0, 0, 0, fmt.Errorf("bad table size %d for %d bits", size, bits)
+	}
+	if lx.MapSizeBits != 0 && (seed != lx.Seed || passes != lx.Passes || bits != lx.MapSizeBits) {
+		return 0, 0, 0, fmt.Errorf("table is for seed %x, passes %d, size %d, expected seed %x, passes %d, size %d",
+			seed, passes, bits, lx.Seed, lx.Passes, lx.MapSizeBits)
+	}
+	return seed, passes, bits, nil
+}
+
+// ExportTable writes the ByteMap, preceded by a header describing it, to w.  The table can be
+// read back with LoadTable.
+func (lx *LXRHash) ExportTable(w io.Writer) error {
+	if lx.MapSize != uint64(1)<<lx.MapSizeBits || uint64(len(lx.ByteMap)) != lx.MapSize {
+		return fmt.Errorf("ByteMap is %d bytes, expected %d", len(lx.ByteMap), lx.MapSize)
+	}
+	if _, err := w.Write(lx.tableHeader()); err != nil {
+		return err
+	}
+	_, err := w.Write(lx.ByteMap)
+	return err
+}
+
+// LoadTable reads a ByteMap written by ExportTable from r.  The header and the size of the
+// table are validated, and r must end with the table.
+//
+// If the MapSizeBits of the LXRHash are set, the table must have the same Seed, Passes and
+// MapSizeBits.  Otherwise they are taken from the table, and HashSize defaults to 256 bits if
+// it is not set.  The ByteMap is released if reading the table fails part way.
+func (lx *LXRHash) LoadTable(r io.Reader) error {
+	h := make([]byte, tableHeaderSize)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return fmt.Errorf("reading table header: %v", err)
+	}
+	seed, passes, bits, err := lx.checkTableHeader(h)
+	if err != nil {
+		return err
+	}
+	if bits > maxAddressableBits {
+		return fmt.Errorf("table of %d bits cannot be addressed on this platform, the largest is %d bits", bits, maxAddressableBits)
+	}
+
+	start := time.Now()
+	lx.Seed = seed
+	lx.Passes = passes
+	lx.MapSizeBits = bits
+	lx.MapSize = uint64(1) << bits
+	if lx.HashSize == 0 {
+		lx.HashSize = (HashSize + 7) / 8
+	}
+
+	lx.ByteMap = lx.allocTable()
+	if _, err := io.ReadFull(r, lx.ByteMap); err != nil {
+		lx.Close()
+		return fmt.Errorf("reading table: %v", err)
+	}
+	switch _, err := io.ReadFull(r, make([]byte, 1)); err {
+	case io.EOF:
+		lx.loaded(SourceLoaded, start)
+		return nil
+	case nil:
+		err = fmt.Errorf("table is longer than %d bytes", tableHeaderSize+lx.MapSize)
+		fallthrough
+	default:
+		lx.Close()
+		return err
+	}
+}
+
 // GenerateTable generates the bytemap.
 // Initializes the map with an incremental sequence of bytes,
 // then does P passes, shuffling each element in a deterministic manner.
 func (lx *LXRHash) GenerateTable() {
-	lx.ByteMap = make([]byte, int(lx.MapSize))
+	lx.generateTable(context.Background())
+}
+
+// generateTable is GenerateTable, which stops and releases the ByteMap once ctx is done
+func (lx *LXRHash) generateTable(ctx context.Context) error {
+	start := time.Now()
+	lx.ByteMap = lx.allocTable()
 	// Our own "random" generator that really is just used to shuffle values
 	offset := lx.Seed ^ firstrand
 	b := lx.Seed ^ firstb
@@ -156,6 +320,10 @@ func (lx *LXRHash) GenerateTable() {
 	for loops := 0; loops < int(lx.Passes); loops++ {
 		lx.Log(fmt.Sprintf("Pass %d", loops))
 		for i := range lx.ByteMap {
+			if i&(1<<20-1) == 0 && ctx.Err() != nil {
+				lx.Close()
+				return ctx.Err()
+			}
 			if (i+1)%1000 == 0 && time.Now().Unix()-period > 10 {
 				lx.Log(fmt.Sprintf(" Index %10d Meg of %10d Meg -- Pass is %5.1f%% Complete", i/1024000, len(lx.ByteMap)/1024000, 100*float64(i)/float64(len(lx.ByteMap))))
 				period = time.Now().Unix()
@@ -166,4 +334,6 @@ func (lx *LXRHash) GenerateTable() {
 		}
 		lx.Log(fmt.Sprintf(" Index %10d Meg of %10d Meg -- Pass is %5.1f%% Complete", len(lx.ByteMap)/1024000, len(lx.ByteMap)/1024000, float64(100)))
 	}
+	lx.loaded(SourceGenerated, start)
+	return nil
 }
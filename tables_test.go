@@ -3,7 +3,9 @@ package lxr
 import (
 	"bytes"
 	"io/ioutil"
+	"math/bits"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -90,8 +92,22 @@ func compareWrite(t *testing.T, MapSizeBits uint64) {
 		panic(err)
 	}
 
-	if !bytes.Equal(a, b) {
-		t.Errorf("mismatch for %d bits. old = %32x, new = %32x", MapSizeBits, a, b)
+	// WriteTable adds a header before the table
+	if !bytes.Equal(b[:tableHeaderSize], l.tableHeader()) {
+		t.Errorf("bad header for %d bits: %x", MapSizeBits, b[:tableHeaderSize])
+	}
+	if !bytes.Equal(a, b[tableHeaderSize:]) {
+		t.Errorf("mismatch for %d bits. old = %32x, new = %32x", MapSizeBits, a, b[tableHeaderSize:])
+	}
+
+	// Both the old and the new table files can be read
+	for _, name := range []string{o.Name(), n.Name()} {
+		r := &LXRHash{Seed: Seed, Passes: Passes, MapSizeBits: MapSizeBits, MapSize: MapSize}
+		if err := r.readFile(name); err != nil {
+			t.Errorf("reading %s: %v", name, err)
+		} else if !bytes.Equal(r.ByteMap, l.ByteMap) {
+			t.Errorf("ByteMap read from %s does not match for %d bits", name, MapSizeBits)
+		}
 	}
 }
 
@@ -101,3 +117,139 @@ func TestLXRHash_WriteTable(t *testing.T) {
 	compareWrite(t, 16)
 	compareWrite(t, 20)
 }
+
+func TestLXRHash_ReadTableLegacy(t *testing.T) {
+	want := new(LXRHash)
+	want.Init(Seed, 10, HashSize, Passes)
+
+	// A table cached without a header, by a release before headers were added
+	l := &LXRHash{Seed: 0x1e9ac1, MapSizeBits: 10, MapSize: 1 << 10, Passes: Passes}
+	filename, legacy, err := l.tableFilename()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(filename)
+	defer os.Remove(legacy)
+	os.Remove(filename)
+	// The table of another seed, so that loading it shows it came from the file
+	if err := ioutil.WriteFile(legacy, want.ByteMap, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The legacy table is read, and neither rewritten nor cached again with a header
+	r := new(LXRHash)
+	r.Init(l.Seed, l.MapSizeBits, HashSize, l.Passes)
+	if r.Source() != SourceLoaded || !bytes.Equal(r.ByteMap, want.ByteMap) {
+		t.Errorf("legacy table not loaded, got a %s table", r.Source())
+	}
+	if b, err := ioutil.ReadFile(legacy); err != nil || !bytes.Equal(b, want.ByteMap) {
+		t.Errorf("legacy table was rewritten: %v", err)
+	}
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("legacy table was cached again: %v", err)
+	}
+
+	// Tables generated since are cached with a header, under the new name only
+	os.Remove(legacy)
+	r.Init(l.Seed, l.MapSizeBits, HashSize, l.Passes)
+	if b, err := ioutil.ReadFile(filename); err != nil || !bytes.Equal(b[:tableHeaderSize], l.tableHeader()) {
+		t.Errorf("generated table was not cached with a header: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("generated table was cached under the legacy name: %v", err)
+	}
+}
+
+func TestLXRHash_LoadTable(t *testing.T) {
+	l := new(LXRHash)
+	l.Init(Seed, 12, HashSize, Passes)
+
+	var buf bytes.Buffer
+	if err := l.ExportTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	table := buf.Bytes()
+
+	// Parameters are taken from the table when not set
+	loaded := new(LXRHash)
+	if err := loaded.LoadTable(bytes.NewReader(table)); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Seed != l.Seed || loaded.Passes != l.Passes || loaded.MapSizeBits != l.MapSizeBits ||
+		loaded.MapSize != l.MapSize || loaded.HashSize != l.HashSize {
+		t.Errorf("parameters not loaded: %+v", loaded)
+	}
+	if !bytes.Equal(loaded.ByteMap, l.ByteMap) {
+		t.Error("loaded ByteMap does not match")
+	}
+	src := []byte("shipped in a container image")
+	if !bytes.Equal(loaded.Hash(src), l.Hash(src)) {
+		t.Error("loaded table hashes differently")
+	}
+
+	corrupt := func(offset int, value byte) []byte {
+		c := append([]byte{}, table...)
+		c[offset] = value
+		return c
+	}
+	bad := []struct {
+		name  string
+		table []byte
+	}{
+		{"empty", nil},
+		{"short header", table[:tableHeaderSize-1]},
+		{"bad magic", corrupt(0, 'X')},
+		{"bad version", corrupt(11, 2)},
+		{"bad size", corrupt(46, 0x20)},
+		{"truncated", table[:len(table)-1]},
+		{"trailing data", append(append([]byte{}, table...), 0)},
+	}
+	for _, b := range bad {
+		if err := new(LXRHash).LoadTable(bytes.NewReader(b.table)); err == nil {
+			t.Errorf("%s: table accepted", b.name)
+		}
+	}
+
+	// Parameters that are set must match the table
+	for _, p := range []Params{{Seed + 1, 12, HashSize, Passes}, {Seed, 13, HashSize, Passes}, {Seed, 12, HashSize, Passes + 1}} {
+		mismatch := &LXRHash{Seed: p.Seed, MapSizeBits: p.MapSizeBits, MapSize: uint64(1) << p.MapSizeBits, Passes: p.Passes}
+		if err := mismatch.LoadTable(bytes.NewReader(table)); err == nil {
+			t.Errorf("table accepted for %+v", p)
+		}
+	}
+
+	if err := new(LXRHash).ExportTable(&buf); err == nil {
+		t.Error("exported an empty table")
+	}
+}
+
+func TestLXRHash_Addressable(t *testing.T) {
+	// Only 32 bit platforms have tables within MaxMapSizeBits that cannot be addressed
+	if maxAddressableBits >= MaxMapSizeBits {
+		t.Skipf("every table up to %d bits can be addressed on a %d bit platform", MaxMapSizeBits, bits.UintSize)
+	}
+	size := maxAddressableBits + 1
+	p := Params{Seed: Seed, MapSizeBits: size, HashSize: HashSize, Passes: Passes}
+
+	if err := p.Validate(); err == nil || !strings.Contains(err.Error(), "cannot be addressed") {
+		t.Errorf("expected a table of %d bits not to be addressable, got %v", size, err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Init accepted a table of %d bits", size)
+			}
+		}()
+		new(LXRHash).Init(Seed, size, HashSize, Passes)
+	}()
+
+	l := &LXRHash{Seed: Seed, Passes: Passes, MapSizeBits: size, MapSize: uint64(1) << size}
+	if err := new(LXRHash).LoadTable(bytes.NewReader(l.tableHeader())); err == nil || !strings.Contains(err.Error(), "cannot be addressed") {
+		t.Errorf("expected LoadTable to refuse a table of %d bits as not addressable, got %v", size, err)
+	}
+
+	// Too large to generate, but could be read from disk were it cached
+	if _, err := NewDiskHash(p, DiskPageSize); err == nil || !strings.Contains(err.Error(), "cannot be generated") {
+		t.Errorf("expected NewDiskHash to refuse generating a table of %d bits, got %v", size, err)
+	}
+}
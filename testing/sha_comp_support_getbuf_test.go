@@ -0,0 +1,17 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package testing_test
+
+import (
+	rand2 "crypto/rand"
+)
+
+func Getbuf(length int) []byte {
+	//buflen := minsample + rand.Intn(maxsample)
+	nbuf := make([]byte, length)
+	_, err := rand2.Reader.Read(nbuf)
+	if err != nil {
+		panic(err)
+	}
+	return nbuf
+}
@@ -5,12 +5,14 @@ package testing_test
 import (
 	"testing"
 	"time"
+
+	"github.com/pegnet/LXRHash/analysis"
 )
 
 func TestAll(t *testing.T) {
 	LX.Init(Seed, MapSizeBits, HashSize, Passes)
 
-	Gradehash{}.PrintHeader()
+	analysis.Gradehash{}.PrintHeader()
 
 	numTests := 1
 	for i := 0; i < numTests; i++ {
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/pegnet/LXRHash/analysis"
 )
 
 func TestCount(t *testing.T) {
 	LX.Init(Seed, MapSizeBits, HashSize, Passes)
 
-	Gradehash{}.PrintHeader()
+	analysis.Gradehash{}.PrintHeader()
 
 	numTests := 1
 	for i := 0; i < numTests; i++ {
@@ -24,8 +26,8 @@ func TestCount(t *testing.T) {
 }
 
 func BitCountTest() {
-	var g1 Gradehash
-	var g2 Gradehash
+	var g1 analysis.Gradehash
+	var g2 analysis.Gradehash
 
 	cnt := int64(0)
 
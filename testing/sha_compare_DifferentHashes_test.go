@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/pegnet/LXRHash/analysis"
 )
 
 func TestDifferentHashes(t *testing.T) {
 	LX.Init(Seed, MapSizeBits, HashSize, Passes)
 
-	Gradehash{}.PrintHeader()
+	analysis.Gradehash{}.PrintHeader()
 
 	numTests := 1
 	for i := 0; i < numTests; i++ {
@@ -24,8 +26,8 @@ func TestDifferentHashes(t *testing.T) {
 }
 
 func DifferentHashes() {
-	var g1 Gradehash
-	var g2 Gradehash
+	var g1 analysis.Gradehash
+	var g2 analysis.Gradehash
 
 	last := time.Now().Unix()
 	cnt := int64(0)
@@ -0,0 +1,86 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+import "fmt"
+
+// Phase is the part of the hash a lookup of the ByteMap was made in
+type Phase int
+
+const (
+	PhaseFast   Phase = iota // The fast spin over the input, one lookup per byte
+	PhaseStep                // The full steps over the input, 25 lookups per byte
+	PhaseReduce              // The reduction to HashSize bytes, 27 lookups per byte of the hash
+)
+
+// Phases lists every Phase in the order they run
+var Phases = []Phase{PhaseFast, PhaseStep, PhaseReduce}
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseFast:
+		return "fast"
+	case PhaseStep:
+		return "step"
+	case PhaseReduce:
+		return "reduce"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// Tracer records the lookups of the ByteMap made by TraceHash, in the order they are made.
+// The index is already masked to the ByteMap.
+type Tracer interface {
+	Lookup(phase Phase, index uint64)
+}
+
+// TracerFunc is a function used as a Tracer
+type TracerFunc func(phase Phase, index uint64)
+
+// Lookup calls f(phase, index)
+func (f TracerFunc) Lookup(phase Phase, index uint64) {
+	f(phase, index)
+}
+
+// Trace is a Tracer keeping every lookup
+type Trace struct {
+	Phases  []Phase
+	Indexes []uint64
+}
+
+// Lookup appends the lookup to the trace
+func (t *Trace) Lookup(phase Phase, index uint64) {
+	t.Phases = append(t.Phases, phase)
+	t.Indexes = append(t.Indexes, index)
+}
+
+// Reset empties the trace, keeping its memory
+func (t *Trace) Reset() {
+	t.Phases = t.Phases[:0]
+	t.Indexes = t.Indexes[:0]
+}
+
+// TraceHash returns the same hash as Hash, calling the tracer with every lookup of the
+// ByteMap made on the way.  It is much slower than Hash, and is meant for studying how the
+// lookups spread over the ByteMap, not for mining.
+func (lx LXRHash) TraceHash(src []byte, t Tracer) []byte {
+	bm := lx.ByteMap
+	return hashLookup(lx.Seed, lx.MapSize, lx.HashSize, [][]byte{src}, func(phase Phase, index uint64) byte {
+		t.Lookup(phase, index)
+		return bm[index]
+	})
+}
+
+// Lookups returns the number of lookups of the ByteMap made in the phase, when hashing an input
+// of inputSize bytes to a hash of hashSize bytes
+func (p Phase) Lookups(inputSize, hashSize uint64) uint64 {
+	switch p {
+	case PhaseFast:
+		return inputSize
+	case PhaseStep:
+		return 25 * inputSize
+	case PhaseReduce:
+		return 27 * hashSize
+	}
+	return 0
+}
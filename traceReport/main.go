@@ -0,0 +1,53 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// traceReport traces the lookups of the ByteMap made while hashing random inputs, and reports
+// how they spread over the ByteMap: the histogram of the indexes, the reuse distances of
+// cache lines and pages compared with uniform lookups, and the lookups per hash, e.g.
+//
+//	go run ./traceReport -n 1000 -input 32
+//
+// Tracing is much slower than hashing, and the reuse of every line and page looked up is
+// kept in memory, so large runs need some patience.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	lxr "github.com/pegnet/LXRHash"
+	"github.com/pegnet/LXRHash/analysis"
+)
+
+func main() {
+	n := flag.Int("n", analysis.DefaultConfig.Samples, "number of inputs to hash")
+	input := flag.Int("input", 32, "size of the random inputs in bytes")
+	inputSeed := flag.Int64("inputseed", 1, "seed of the random inputs")
+	seed := flag.Uint64("seed", lxr.Seed, "seed of the ByteMap")
+	bits := flag.Uint64("bits", lxr.MapSizeBits, "MapSizeBits of the ByteMap")
+	passes := flag.Uint64("passes", lxr.Passes, "Passes to generate the ByteMap")
+	size := flag.Uint64("size", lxr.HashSize, "HashSize in bits")
+	flag.Parse()
+
+	p := lxr.Params{Seed: *seed, MapSizeBits: *bits, HashSize: *size, Passes: *passes}
+	if err := p.Validate(); err != nil {
+		fail(err)
+	}
+	if *n < 1 || *input < 0 {
+		fail(fmt.Errorf("need at least one input of 0 or more bytes, not %d of %d", *n, *input))
+	}
+	lx := new(lxr.LXRHash)
+	lx.Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes)
+
+	cfg := analysis.DefaultConfig
+	cfg.Samples, cfg.InputSize, cfg.Seed = *n, *input, *inputSeed
+	profile := analysis.ProfileHash(*lx, cfg)
+	fmt.Println(profile)
+	fmt.Print(analysis.Report{Name: "Verdicts against uniform lookups", Results: profile.Results(cfg)})
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "traceReport:", err)
+	os.Exit(1)
+}
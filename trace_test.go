@@ -0,0 +1,54 @@
+package lxr
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLXRHash_TraceHash(t *testing.T) {
+	for _, l := range fastParams() {
+		for _, size := range []int{0, 1, 31, 100} {
+			src := make([]byte, size)
+			for i := range src {
+				src[i] = byte(i*7 + size)
+			}
+
+			var trace Trace
+			// TraceHash shares the body of Hash, so compare with the separate steps of FlatHash
+			if got, want := l.TraceHash(src, &trace), l.FlatHash(src); !bytes.Equal(got, want) {
+				t.Fatalf("%s: traced hash of %d bytes is %x, expected %x", paramName(l), size, got, want)
+			}
+
+			counts := make(map[Phase]uint64)
+			last := PhaseFast
+			for i, p := range trace.Phases {
+				if p < last {
+					t.Fatalf("%s: phase %s after %s", paramName(l), p, last)
+				}
+				last = p
+				counts[p]++
+				if trace.Indexes[i] >= l.MapSize {
+					t.Fatalf("%s: index %d outside the ByteMap", paramName(l), trace.Indexes[i])
+				}
+			}
+			for _, p := range Phases {
+				if want := p.Lookups(uint64(size), l.HashSize); counts[p] != want {
+					t.Errorf("%s: %d lookups in the %s phase of %d bytes, expected %d", paramName(l), counts[p], p, size, want)
+				}
+			}
+
+			// A TracerFunc sees the same lookups
+			n := 0
+			l.TraceHash(src, TracerFunc(func(p Phase, index uint64) {
+				if n < len(trace.Indexes) && (p != trace.Phases[n] || index != trace.Indexes[n]) {
+					t.Fatalf("%s: lookup %d differs", paramName(l), n)
+				}
+				n++
+			}))
+			if n != len(trace.Indexes) {
+				t.Errorf("%s: %d lookups traced by the func, expected %d", paramName(l), n, len(trace.Indexes))
+			}
+			trace.Reset()
+		}
+	}
+}
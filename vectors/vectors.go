@@ -0,0 +1,192 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// Package vectors reads and writes the LXRHash test vector corpus in testdata/vectors.json.
+// The corpus lists the hash of known inputs under a range of parameters, and is meant for
+// checking ports of LXRHash to other languages against this implementation.
+//
+// The corpus is a JSON object with the format version, a description and a list of vectors.
+// Each vector gives the seed as 16 hex digits, the MapSizeBits, the HashSize in bits and the
+// Passes used to build the LXRHash, with the input and the resulting hash in hex.  The mode
+// of a vector says how the input is hashed: by Hash if it is empty, by KeyedHash with the
+// key of the vector in hex if it is "keyed", or by PersonalizedHash with the tag of the
+// vector in hex if it is "personalized".
+package vectors
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+// Version is the version of the corpus format.  It changes if the format changes, or if
+// vectors are ever changed or removed rather than added.  Version 2 added the keyed and
+// personalized vectors.
+const Version = 2
+
+// Modes of hashing the input of a vector
+const (
+	ModeHash         = ""             // Hash of the input
+	ModeKeyed        = "keyed"        // KeyedHash of the input with the key
+	ModePersonalized = "personalized" // PersonalizedHash of the input with the tag
+)
+
+// Vector is a single hash in the corpus
+type Vector struct {
+	Seed        string `json:"seed"`           // Seed as 16 hex digits
+	MapSizeBits uint64 `json:"mapSizeBits"`    // Size of the ByteMap in bits
+	HashSize    uint64 `json:"hashSize"`       // Number of bits in the hash
+	Passes      uint64 `json:"passes"`         // Passes to generate the ByteMap
+	Mode        string `json:"mode,omitempty"` // How the input is hashed
+	Key         string `json:"key,omitempty"`  // Key in hex, for keyed vectors
+	Tag         string `json:"tag,omitempty"`  // Tag in hex, for personalized vectors
+	Input       string `json:"input"`          // Input in hex
+	Output      string `json:"output"`         // Hash of the input in hex
+}
+
+// Corpus is the set of test vectors
+type Corpus struct {
+	Version     int      `json:"version"`
+	Description string   `json:"description"`
+	Vectors     []Vector `json:"vectors"`
+}
+
+// New returns the hash of src by lx as a Vector
+func New(lx *lxr.LXRHash, src []byte) Vector {
+	return Vector{
+		Seed:        fmt.Sprintf("%016x", lx.Seed),
+		MapSizeBits: lx.MapSizeBits,
+		HashSize:    lx.HashSize * 8,
+		Passes:      lx.Passes,
+		Input:       hex.EncodeToString(src),
+		Output:      hex.EncodeToString(lx.Hash(src)),
+	}
+}
+
+// NewKeyed returns the KeyedHash of src with key by lx as a Vector
+func NewKeyed(lx *lxr.LXRHash, key, src []byte) Vector {
+	v := New(lx, src)
+	v.Mode = ModeKeyed
+	v.Key = hex.EncodeToString(key)
+	v.Output = hex.EncodeToString(lx.KeyedHash(key, src))
+	return v
+}
+
+// NewPersonalized returns the PersonalizedHash of src with tag by lx as a Vector
+func NewPersonalized(lx *lxr.LXRHash, tag, src []byte) Vector {
+	v := New(lx, src)
+	v.Mode = ModePersonalized
+	v.Tag = hex.EncodeToString(tag)
+	v.Output = hex.EncodeToString(lx.PersonalizedHash(tag, src))
+	return v
+}
+
+// Params returns the Params of the LXRHash for the vector
+func (v Vector) Params() (lxr.Params, error) {
+	seed, err := strconv.ParseUint(v.Seed, 16, 64)
+	if err != nil {
+		return lxr.Params{}, fmt.Errorf("bad seed %q: %v", v.Seed, err)
+	}
+	return lxr.Params{Seed: seed, MapSizeBits: v.MapSizeBits, HashSize: v.HashSize, Passes: v.Passes}, nil
+}
+
+// Decode returns the input and the expected output of the vector
+func (v Vector) Decode() (input, output []byte, err error) {
+	if input, err = hex.DecodeString(v.Input); err != nil {
+		return nil, nil, fmt.Errorf("bad input %q: %v", v.Input, err)
+	}
+	if output, err = hex.DecodeString(v.Output); err != nil {
+		return nil, nil, fmt.Errorf("bad output %q: %v", v.Output, err)
+	}
+	if uint64(len(output)) != (v.HashSize+7)/8 {
+		return nil, nil, fmt.Errorf("output is %d bytes for a %d bit hash", len(output), v.HashSize)
+	}
+	return input, output, nil
+}
+
+// Hash hashes the input of the vector by lx in the mode of the vector
+func (v Vector) Hash(lx *lxr.LXRHash, input []byte) ([]byte, error) {
+	switch v.Mode {
+	case ModeHash:
+		return lx.Hash(input), nil
+	case ModeKeyed:
+		key, err := hex.DecodeString(v.Key)
+		if err != nil {
+			return nil, fmt.Errorf("bad key %q: %v", v.Key, err)
+		}
+		return lx.KeyedHash(key, input), nil
+	case ModePersonalized:
+		tag, err := hex.DecodeString(v.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("bad tag %q: %v", v.Tag, err)
+		}
+		return lx.PersonalizedHash(tag, input), nil
+	}
+	return nil, fmt.Errorf("unknown mode %q", v.Mode)
+}
+
+// Read reads a corpus, which must be of the current Version or an earlier one
+func Read(r io.Reader) (*Corpus, error) {
+	c := new(Corpus)
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, err
+	}
+	if c.Version < 1 || c.Version > Version {
+		return nil, fmt.Errorf("unsupported corpus version %d, expected up to %d", c.Version, Version)
+	}
+	return c, nil
+}
+
+// ReadFile reads a corpus from the named file
+func ReadFile(filename string) (*Corpus, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Sort orders the vectors by their parameters and mode, then by the length and value of the
+// key, the tag and the input
+func (c *Corpus) Sort() {
+	sort.SliceStable(c.Vectors, func(i, j int) bool {
+		a, b := c.Vectors[i], c.Vectors[j]
+		switch {
+		case a.Seed != b.Seed:
+			return a.Seed < b.Seed
+		case a.MapSizeBits != b.MapSizeBits:
+			return a.MapSizeBits < b.MapSizeBits
+		case a.Passes != b.Passes:
+			return a.Passes < b.Passes
+		case a.HashSize != b.HashSize:
+			return a.HashSize < b.HashSize
+		case a.Mode != b.Mode:
+			return a.Mode < b.Mode
+		case len(a.Key) != len(b.Key):
+			return len(a.Key) < len(b.Key)
+		case a.Key != b.Key:
+			return a.Key < b.Key
+		case len(a.Tag) != len(b.Tag):
+			return len(a.Tag) < len(b.Tag)
+		case a.Tag != b.Tag:
+			return a.Tag < b.Tag
+		case len(a.Input) != len(b.Input):
+			return len(a.Input) < len(b.Input)
+		}
+		return a.Input < b.Input
+	})
+}
+
+// Write writes the corpus as indented JSON
+func (c *Corpus) Write(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(c)
+}
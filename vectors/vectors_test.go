@@ -0,0 +1,120 @@
+package vectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	lxr "github.com/pegnet/LXRHash"
+)
+
+func TestCorpus(t *testing.T) {
+	corpus, err := ReadFile("testdata/vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corpus.Vectors) == 0 {
+		t.Fatal("empty corpus")
+	}
+
+	hashes := make(map[lxr.Params]*lxr.LXRHash)
+	for _, v := range corpus.Vectors {
+		p, err := v.Params()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.MapSizeBits > 20 && testing.Short() {
+			continue
+		}
+		input, output, err := v.Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lx, ok := hashes[p]
+		if !ok {
+			lx = new(lxr.LXRHash)
+			lx.Init(p.Seed, p.MapSizeBits, p.HashSize, p.Passes)
+			hashes[p] = lx
+		}
+
+		if v.Mode == ModeKeyed {
+			key, _ := hex.DecodeString(v.Key)
+			if got := lx.KeyedHash(key, input); !bytes.Equal(got, output) {
+				t.Errorf("KeyedHash %+v: got %x", v, got)
+			}
+			h := lx.NewKeyedHash(key)
+			h.Write(input[:len(input)/2])
+			h.Write(input[len(input)/2:])
+			if got := h.Sum(nil); !bytes.Equal(got, output) {
+				t.Errorf("NewKeyedHash %+v: got %x", v, got)
+			}
+			continue
+		}
+		if v.Mode == ModePersonalized {
+			tag, _ := hex.DecodeString(v.Tag)
+			if got := lx.PersonalizedHash(tag, input); !bytes.Equal(got, output) {
+				t.Errorf("PersonalizedHash %+v: got %x", v, got)
+			}
+			h := lx.NewPersonalizedHash(tag)
+			h.Write(input[:len(input)/2])
+			h.Write(input[len(input)/2:])
+			if got := h.Sum(nil); !bytes.Equal(got, output) {
+				t.Errorf("NewPersonalizedHash %+v: got %x", v, got)
+			}
+			continue
+		}
+
+		if got, err := v.Hash(lx, input); err != nil || !bytes.Equal(got, output) {
+			t.Errorf("Hash %+v: got %x, %v", v, got, err)
+		}
+		if got := lx.FlatHash(input); !bytes.Equal(got, output) {
+			t.Errorf("FlatHash %+v: got %x", v, got)
+		}
+		// Split the input between the base and the batch at the start, middle and end
+		for _, split := range []int{0, len(input) / 2, len(input)} {
+			got := lx.HashParallel(input[:split], [][]byte{input[split:], input[split:]})
+			if !bytes.Equal(got[0], output) || !bytes.Equal(got[1], output) {
+				t.Errorf("HashParallel %+v split at %d: got %x", v, split, got)
+			}
+		}
+		for _, impl := range lxr.Implementations {
+			got := lx.Batch(impl)(input[:len(input)/2], [][]byte{input[len(input)/2:]})
+			if !bytes.Equal(got[0], output) {
+				t.Errorf("%s %+v: got %x", impl, v, got[0])
+			}
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	for _, version := range []int{0, Version + 1} {
+		if _, err := Read(strings.NewReader(fmt.Sprintf(`{"version": %d, "vectors": []}`, version))); err == nil {
+			t.Errorf("read a corpus of unsupported version %d", version)
+		}
+	}
+	if _, err := Read(strings.NewReader(`{"version": 1, "vectors": []}`)); err != nil {
+		t.Errorf("failed to read a version 1 corpus: %v", err)
+	}
+
+	bad := []Vector{
+		{Seed: "xyz", HashSize: 256, Output: ""},
+		{Seed: "fafaececfafaecec", HashSize: 256, Input: "0", Output: ""},
+		{Seed: "fafaececfafaecec", HashSize: 256, Output: "00"},
+		{Seed: "fafaececfafaecec", HashSize: 8, Output: "00", Mode: ModeKeyed, Key: "xyz"},
+		{Seed: "fafaececfafaecec", HashSize: 8, Output: "00", Mode: ModePersonalized, Tag: "xyz"},
+		{Seed: "fafaececfafaecec", HashSize: 8, Output: "00", Mode: "tagged"},
+	}
+	lx := new(lxr.LXRHash)
+	lx.Init(lxr.Seed, 8, 8, lxr.Passes)
+	for _, v := range bad {
+		_, errParams := v.Params()
+		_, _, errDecode := v.Decode()
+		_, errHash := v.Hash(lx, nil)
+		if errParams == nil && errDecode == nil && errHash == nil {
+			t.Errorf("accepted bad vector %+v", v)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package lxr
+
+import "testing"
+
+// TestLXRHash_VerifyTable generates the tables up to 20 bits, so that any change to
+// GenerateTable is caught rather than hidden by the disk cache.  Larger tables take minutes
+// to generate, so they are checked only when they are already cached.
+func TestLXRHash_VerifyTable(t *testing.T) {
+	for bits := uint64(8); bits <= 20; bits++ {
+		l := &LXRHash{Seed: Seed, MapSizeBits: bits, MapSize: uint64(1) << bits, HashSize: HashSize / 8, Passes: Passes}
+		l.GenerateTable()
+		if err := l.VerifyTable(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if err := lx.VerifyTable(); err != nil {
+		t.Error(err)
+	}
+
+	if testing.Short() {
+		t.Skip("skipping cached tables over 20 bits in short mode")
+	}
+	for bits := uint64(21); bits < 30; bits++ {
+		l := &LXRHash{Seed: Seed, MapSizeBits: bits, MapSize: uint64(1) << bits, HashSize: HashSize / 8, Passes: Passes}
+		filename, legacy, err := l.tableFilename()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !l.cached(filename) {
+			filename = legacy
+		}
+		if !l.cached(filename) {
+			t.Logf("%d bit table is not cached", bits)
+			continue
+		}
+		if err := l.readFile(filename); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.VerifyTable(); err != nil {
+			t.Error(err)
+		}
+		l.Close()
+	}
+}
+
+func TestLXRHash_VerifyTableMismatch(t *testing.T) {
+	l := new(LXRHash)
+	l.Init(Seed, 10, HashSize, Passes)
+	if err := l.VerifyTable(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swapping two entries keeps the byte counts but changes the table
+	c := &LXRHash{Seed: l.Seed, MapSizeBits: l.MapSizeBits, MapSize: l.MapSize, Passes: l.Passes}
+	c.ByteMap = append([]byte{}, l.ByteMap...)
+	c.ByteMap[0], c.ByteMap[1] = c.ByteMap[1], c.ByteMap[0]
+	if c.ByteMap[0] != c.ByteMap[1] {
+		if err := c.VerifyTable(); err == nil {
+			t.Error("swapped table verified")
+		}
+	}
+
+	for _, p := range []Params{{Seed + 1, 10, HashSize, Passes}, {Seed, 10, HashSize, Passes + 1}, {Seed, 31, HashSize, Passes}} {
+		if _, ok := KnownDigest(p); ok {
+			t.Errorf("digest known for %+v", p)
+		}
+	}
+	other := new(LXRHash)
+	other.Init(Seed+1, 10, HashSize, Passes)
+	if err := other.VerifyTable(); err == nil {
+		t.Error("verified a table with no known digest")
+	}
+}
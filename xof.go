@@ -0,0 +1,50 @@
+// Copyright (c) of parts are held by the various contributors
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package lxr
+
+// XOF reads any number of bytes of output from one input, with the ByteMap of an LXRHash.
+// The output is made of blocks of HashSize bytes.  The first block is the Hash of the
+// input.  Each further block comes from another reduction pass over the state left by the
+// one before, stepping the hash with every value of hs in turn just as the reduction of
+// Hash does, so reading more output never needs another instance with a larger HashSize.
+type XOF struct {
+	reduce func(out []byte) // Reduction pass of the body of Hash over the absorbed input
+	block  []byte           // The current block of output
+	off    int              // Bytes of block already read
+}
+
+// NewXOF absorbs src, and returns an XOF reading the output for it.  It panics if the
+// LXRHash has no HashSize, such as one that was never initialised, as the blocks of output
+// would be empty.
+func (lx LXRHash) NewXOF(src []byte) *XOF {
+	if lx.HashSize == 0 {
+		panic("NewXOF of an LXRHash with a HashSize of 0")
+	}
+	bm := lx.ByteMap
+	x := &XOF{
+		reduce: absorbLookup(lx.Seed, lx.MapSize, lx.HashSize, [][]byte{src}, func(_ Phase, index uint64) byte { return bm[index] }),
+		block:  make([]byte, lx.HashSize),
+	}
+	x.next()
+	return x
+}
+
+// next computes the next block of output with a reduction pass
+func (x *XOF) next() {
+	x.reduce(x.block)
+	x.off = 0
+}
+
+// Read fills p with the next bytes of output.  It always fills p, and never returns an error.
+func (x *XOF) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if x.off == len(x.block) {
+			x.next()
+		}
+		c := copy(p[n:], x.block[x.off:])
+		x.off += c
+		n += c
+	}
+	return n, nil
+}
@@ -0,0 +1,64 @@
+package lxr
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestLXRHash_NewXOF(t *testing.T) {
+	src := []byte("extendable output")
+	for _, l := range fastParams() {
+		want := make([]byte, 5*l.HashSize+3)
+		if _, err := io.ReadFull(l.NewXOF(src), want); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want[:l.HashSize], l.Hash(src)) {
+			t.Errorf("%s: first block %x differs from the hash %x", paramName(l), want[:l.HashSize], l.Hash(src))
+		}
+
+		// The output does not depend on how it is read
+		for _, chunk := range []int{1, 7, int(l.HashSize), len(want)} {
+			x := l.NewXOF(src)
+			var got []byte
+			for len(got) < len(want) {
+				buf := make([]byte, chunk)
+				if n, err := x.Read(buf); n != chunk || err != nil {
+					t.Fatalf("%s: read %d of %d bytes, %v", paramName(l), n, chunk, err)
+				}
+				got = append(got, buf...)
+			}
+			if !bytes.Equal(got[:len(want)], want) {
+				t.Errorf("%s: reading %d bytes at a time got %x, want %x", paramName(l), chunk, got[:len(want)], want)
+			}
+		}
+	}
+}
+
+func TestLXRHash_NewXOFBlocks(t *testing.T) {
+	src := []byte("extendable output")
+	for _, l := range distinctParams() {
+		want := make([]byte, 5*l.HashSize)
+		l.NewXOF(src).Read(want)
+		for i := uint64(1); i < 5; i++ {
+			if bytes.Equal(want[(i-1)*l.HashSize:i*l.HashSize], want[i*l.HashSize:(i+1)*l.HashSize]) {
+				t.Errorf("%s: block %d repeats the one before", paramName(l), i)
+			}
+		}
+		other := make([]byte, len(want))
+		l.NewXOF(append(src, 0)).Read(other)
+		if bytes.Equal(other[l.HashSize:], want[l.HashSize:]) {
+			t.Errorf("%s: different inputs give the same extended output", paramName(l))
+		}
+	}
+}
+
+func TestLXRHash_NewXOFZeroHashSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewXOF of a zero LXRHash did not panic")
+		}
+	}()
+	var zero LXRHash
+	zero.NewXOF([]byte("never read")).Read(make([]byte, 1))
+}